@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/cover"
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+// coverCmd prints statistics of the plans visiting every reachable cell of the
+// table or the command file of the chosen plan
+func coverCmd(params []string) int {
+	fs := flag.NewFlagSet("cover", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table without blocked cells")
+	layoutFile := fs.String("layout", "", "file with rows of '.' free and '#' blocked cells, it overrides --size")
+	from := fs.String("from", "0,0,EAST", "pose the robot starts at")
+	strategyName := fs.String("strategy", "", "coverage strategy, BOUSTROPHEDON or SPIRAL, all of them are compared when empty")
+	emit := fs.Bool("emit", false, "print the command file of the plan instead of its statistics")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+
+	layout, err := loadLayout(*size, *layoutFile)
+	if err != nil {
+		fmt.Printf("invalid table: %s\n", err.Error())
+		return 2
+	}
+	start, err := table.ParsePose(*from)
+	if err != nil {
+		fmt.Printf("invalid start pose: %s\n", err.Error())
+		return 2
+	}
+
+	strategies := cover.Strategies
+	if *strategyName != "" {
+		strategy, err := cover.ParseStrategy(*strategyName)
+		if err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return 2
+		}
+		strategies = []cover.Strategy{strategy}
+	}
+	if *emit {
+		strategies = strategies[:1]
+	}
+
+	for _, strategy := range strategies {
+		res, err := cover.Plan(layout, start, strategy)
+		if err != nil {
+			fmt.Printf("failed to plan coverage: %s\n", err.Error())
+			return 1
+		}
+		if *emit {
+			os.Stdout.Write(plan.Source(start, res.Steps))
+			continue
+		}
+		fmt.Printf("%s: %s\n", strategy, res)
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"robot/internal/command"
+	"robot/internal/equiv"
+	"robot/internal/table"
+)
+
+// equivCmd proves that two command files report the same from every start
+// state of the table, the exit code is non zero when they diverge
+func equivCmd(params []string) int {
+	fs := flag.NewFlagSet("equiv", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table the command files are compared on")
+	maxSteps := fs.Int("max-steps", equiv.DefaultMaxSteps, "give up once a run executed the number of commands")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 2 {
+		fmt.Printf("missing file names from the argument list\n")
+		fmt.Printf("expected usage: ./robot equiv a.txt b.txt [--size 5x5] [--max-steps 1000]\n")
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Printf("invalid table size: %s\n", err.Error())
+		return 2
+	}
+
+	if *maxSteps <= 0 {
+		fmt.Printf("max steps must be positive\n")
+		return 2
+	}
+
+	progs := make([]*command.Program, 0, len(args))
+	for _, fileName := range args {
+		prog, err := command.Load(fileName)
+		if err != nil {
+			fmt.Printf("failed to scan command list: %s\n", err.Error())
+			return 1
+		}
+		progs = append(progs, prog)
+	}
+
+	d, err := equiv.Check(progs[0], progs[1], equiv.Config{SizeX: sizeX, SizeY: sizeY, MaxSteps: *maxSteps})
+	if errors.Is(err, equiv.ErrInconclusive) {
+		fmt.Printf("%s\n", err.Error())
+		return 1
+	}
+	if err != nil {
+		fmt.Printf("failed to compare command lists: %s\n", err.Error())
+		return 1
+	}
+	if d != nil {
+		fmt.Printf("%s\n", d)
+		return 1
+	}
+	fmt.Printf("%s and %s are equivalent on %dx%d table\n", args[0], args[1], sizeX, sizeY)
+	return 0
+}
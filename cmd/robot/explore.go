@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/explore"
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+// exploreCmd prints the map the robot discovers on a table it does not know
+// alongside the steps taken or the command file of the exploration
+func exploreCmd(params []string) int {
+	fs := flag.NewFlagSet("explore", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table without blocked cells")
+	layoutFile := fs.String("layout", "", "file with rows of '.' free and '#' blocked cells, it overrides --size")
+	from := fs.String("from", "0,0,EAST", "pose the robot starts at")
+	strategyName := fs.String("strategy", "", "exploration strategy, LEFTHAND or FRONTIER, all of them are compared when empty")
+	emit := fs.Bool("emit", false, "print the command file of the exploration instead of the discovered map")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+
+	layout, err := loadLayout(*size, *layoutFile)
+	if err != nil {
+		fmt.Printf("invalid table: %s\n", err.Error())
+		return 2
+	}
+	start, err := table.ParsePose(*from)
+	if err != nil {
+		fmt.Printf("invalid start pose: %s\n", err.Error())
+		return 2
+	}
+
+	strategies := explore.Strategies
+	if *strategyName != "" {
+		strategy, err := explore.ParseStrategy(*strategyName)
+		if err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return 2
+		}
+		strategies = []explore.Strategy{strategy}
+	}
+	if *emit {
+		strategies = strategies[:1]
+	}
+
+	for _, strategy := range strategies {
+		res, err := explore.Explore(layout, start, strategy)
+		if err != nil {
+			fmt.Printf("failed to explore: %s\n", err.Error())
+			return 1
+		}
+		if *emit {
+			os.Stdout.Write(plan.Source(start, res.Steps))
+			continue
+		}
+		fmt.Printf("%s: %s\n%s", strategy, res, res.Map)
+	}
+	return 0
+}
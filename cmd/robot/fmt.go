@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/command"
+)
+
+// fmtCmd rewrites command files in the canonical form, with --check files are
+// only listed when they are not formatted
+func fmtCmd(params []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := fs.Bool("check", false, "list files that are not formatted instead of rewriting them")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) == 0 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot fmt [--check] commands.txt...\n")
+		return 2
+	}
+
+	code := 0
+	for _, fileName := range args {
+		src, err := os.ReadFile(fileName)
+		if err != nil {
+			fmt.Printf("failed to read command file: %s\n", err.Error())
+			code = 1
+			continue
+		}
+
+		f, err := command.Parse(fileName, src)
+		if err != nil {
+			fmt.Printf("failed to parse command file: %s\n", err.Error())
+			code = 1
+			continue
+		}
+
+		formatted := command.Format(f)
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+		if *check {
+			fmt.Printf("%s\n", fileName)
+			code = 1
+			continue
+		}
+		if err := os.WriteFile(fileName, formatted, 0o644); err != nil {
+			fmt.Printf("failed to write command file: %s\n", err.Error())
+			code = 1
+		}
+	}
+	return code
+}
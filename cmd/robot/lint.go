@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"robot/internal/command"
+	"robot/internal/lint"
+	"robot/internal/table"
+)
+
+// lintCmd checks command files without running them and lists the findings,
+// the exit code is non zero when there are any
+func lintCmd(params []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table command files are checked against")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) == 0 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot lint [--size 5x5] commands.txt...\n")
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Printf("invalid table size: %s\n", err.Error())
+		return 2
+	}
+
+	code := 0
+	for _, fileName := range args {
+		prog, err := command.Load(fileName)
+		if err != nil {
+			fmt.Printf("failed to scan command list: %s\n", err.Error())
+			code = 1
+			continue
+		}
+
+		for _, finding := range lint.Lint(prog, lint.Config{SizeX: sizeX, SizeY: sizeY}) {
+			fmt.Printf("%s\n", finding)
+			code = 1
+		}
+	}
+	return code
+}
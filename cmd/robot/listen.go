@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"robot/internal/lineserver"
+	"robot/internal/table"
+)
+
+// listenCmd serves commands received as lines over TCP
+func listenCmd(params []string) int {
+	fs := flag.NewFlagSet("listen", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the tables")
+	shared := fs.Bool("shared", false, "drive a single table shared by every connection instead of a table per connection")
+	idleTimeout := fs.Duration("idle-timeout", 0, "close connections that sent no line for the duration, e.g. 5m")
+	maxConns := fs.Int("max-conns", 0, "maximum number of connections served at once, no limit when zero")
+	maxSteps := fs.Int("max-steps", lineserver.DefaultMaxSteps, "stop a line after executing the number of commands, no limit when zero")
+	timeout := fs.Duration("timeout", lineserver.DefaultTimeout, "stop a line after the duration, no limit when zero")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) > 1 {
+		fmt.Printf("too many arguments\n")
+		fmt.Printf("expected usage: ./robot listen [:4000] [--size 5x5] [--shared] [--idle-timeout 5m] [--max-conns 100] [--max-steps 1000] [--timeout 5s]\n")
+		return 2
+	}
+	addr := ":4000"
+	if len(args) == 1 {
+		addr = args[0]
+	}
+
+	if *timeout < 0 || *maxSteps < 0 {
+		fmt.Printf("timeout and max steps can not be negative\n")
+		return 2
+	}
+	if *idleTimeout < 0 || *maxConns < 0 {
+		fmt.Printf("idle timeout and max conns can not be negative\n")
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Printf("invalid table size: %s\n", err.Error())
+		return 2
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Printf("failed to listen: %s\n", err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "listening on %s\n", l.Addr())
+
+	srv := lineserver.New(lineserver.Config{
+		SizeX:       sizeX,
+		SizeY:       sizeY,
+		Shared:      *shared,
+		IdleTimeout: *idleTimeout,
+		MaxConns:    *maxConns,
+		MaxSteps:    *maxSteps,
+		Timeout:     *timeout,
+	})
+	if err := srv.Serve(l); err != nil {
+		fmt.Printf("server failed: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/lsp"
+	"robot/internal/table"
+)
+
+// lspCmd serves the Language Server Protocol over stdin and stdout
+func lspCmd(params []string) int {
+	fs := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table documents are simulated on")
+	from := fs.String("from", "", "PLACE parameters of the robot before documents are simulated, e.g. 0,0,NORTH")
+	maxSteps := fs.Int("max-steps", lsp.DefaultMaxSteps, "stop simulating a document after executing the number of commands")
+	timeout := fs.Duration("timeout", lsp.DefaultTimeout, "stop simulating a document after the duration")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+	if *timeout <= 0 || *maxSteps <= 0 {
+		fmt.Fprintf(os.Stderr, "timeout and max steps have to be positive\n")
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid table size: %s\n", err.Error())
+		return 2
+	}
+
+	srv := lsp.New(lsp.Config{SizeX: sizeX, SizeY: sizeY, Start: *from, MaxSteps: *maxSteps, Timeout: *timeout})
+	if err := srv.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "language server failed: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
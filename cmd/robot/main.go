@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 
@@ -8,23 +10,118 @@ import (
 	"robot/internal/table"
 )
 
+// subcommands map names to entry points that receive the arguments following
+// the name and return the exit code
+var subcommands = map[string]func(args []string) int{
+	"cover":    coverCmd,
+	"equiv":    equivCmd,
+	"explore":  exploreCmd,
+	"fmt":      fmtCmd,
+	"help":     helpCmd,
+	"lint":     lintCmd,
+	"listen":   listenCmd,
+	"lsp":      lspCmd,
+	"maze":     mazeCmd,
+	"optimize": optimizeCmd,
+	"plan":     planCmd,
+	"rpc":      rpcCmd,
+	"serve":    serveCmd,
+	"simulate": simulateCmd,
+	"verify":   verifyCmd,
+}
+
 func main() {
 	params := os.Args[1:]
 	if len(params) == 0 {
 		fmt.Printf("missing file name from the argument list\n")
-		fmt.Printf("expected usage: ./robot commands.txt\n")
+		fmt.Printf("expected usage: ./robot [--timeout 5s] [--max-steps 1000] commands.txt\n")
+		fmt.Printf("                ./robot cover|equiv|explore|fmt|help|lint|listen|lsp|maze|optimize|plan|rpc|serve|simulate|verify [flags]\n")
 		os.Exit(1)
 	}
-	fileName := params[0]
+
+	if sub, ok := subcommands[params[0]]; ok {
+		os.Exit(sub(params[1:]))
+	}
+	os.Exit(runCmd(params))
+}
+
+// runCmd runs commands of the file on 5x5 table
+func runCmd(params []string) int {
+	fs := flag.NewFlagSet("robot", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 0, "stop the run after the duration, e.g. 5s, no limit when zero")
+	maxSteps := fs.Int("max-steps", 0, "stop the run after executing the number of commands, no limit when zero")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot [--timeout 5s] [--max-steps 1000] commands.txt\n")
+		return 2
+	}
+	if *timeout < 0 || *maxSteps < 0 {
+		fmt.Printf("timeout and max steps can not be negative\n")
+		return 2
+	}
 
 	tbl := table.New(5, 5)
-	cmds, err := command.ScanCommandList(fileName)
+	cmds, err := command.ScanCommandList(args[0])
 	if err != nil {
 		fmt.Printf("failed to scan command list: %s\n", err.Error())
-		os.Exit(1)
+		return 1
 	}
 
-	for _, cmd := range cmds {
-		cmd(tbl)
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	if err := command.RunContext(ctx, tbl, cmds, command.WithMaxSteps(*maxSteps)); err != nil {
+		fmt.Printf("failed to run command list: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// helpCmd prints usage of every command
+func helpCmd(params []string) int {
+	fmt.Print(command.DefaultRegistry.Help())
+	fmt.Printf("INCLUDE \"file\"\n    includes commands of the file, the path is relative to the including file\n")
+	fmt.Printf("DEF name ... END\n    defines a procedure\n")
+	fmt.Printf("CALL name\n    executes commands of the procedure\n")
+	fmt.Printf("REPEAT count ... END\n    executes commands of the block the given number of times\n")
+	fmt.Printf("# comment\n    ignored until the end of line\n")
+	return 0
+}
+
+// parseInterspersed parses flags that may follow the positional arguments
+// and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, params []string) ([]string, error) {
+	args := []string{}
+	for {
+		if err := fs.Parse(params); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return args, nil
+		}
+		args = append(args, fs.Arg(0))
+		params = fs.Args()[1:]
+	}
+}
+
+// parseFlags parses flags of subcommands that take no positional arguments,
+// arguments that are not flags are rejected instead of being ignored
+func parseFlags(fs *flag.FlagSet, params []string) error {
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		err := fmt.Errorf("unexpected argument: '%s'", args[0])
+		fmt.Fprintf(fs.Output(), "%s\n", err.Error())
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/maze"
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+// mazeCmd prints the layout of a generated maze or the command file taking the
+// robot from its entrance to its exit
+func mazeCmd(params []string) int {
+	fs := flag.NewFlagSet("maze", flag.ContinueOnError)
+	size := fs.String("size", "21x21", "size of the maze, odd sizes leave no blocked border")
+	algorithmName := fs.String("algorithm", "BACKTRACKER", "generation algorithm, BACKTRACKER, PRIM or ROOMS")
+	seed := fs.Int64("seed", 1, "seed of the generator, the same seed gives the same maze")
+	solve := fs.Bool("solve", false, "print the command file solving the maze instead of its layout")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Printf("invalid maze size: %s\n", err.Error())
+		return 2
+	}
+	algorithm, err := maze.ParseAlgorithm(*algorithmName)
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+		return 2
+	}
+
+	m, err := maze.Generate(sizeX, sizeY, algorithm, *seed)
+	if err != nil {
+		fmt.Printf("failed to generate maze: %s\n", err.Error())
+		return 1
+	}
+	if !*solve {
+		fmt.Print(m.String())
+		return 0
+	}
+
+	steps, err := m.Solve()
+	if err != nil {
+		fmt.Printf("failed to solve maze: %s\n", err.Error())
+		return 1
+	}
+	os.Stdout.Write(plan.Source(m.Start(), steps))
+	return 0
+}
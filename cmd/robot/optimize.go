@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/command"
+	"robot/internal/equiv"
+	"robot/internal/optimize"
+	"robot/internal/table"
+)
+
+// optimizeCmd prints the command file rewritten to an equivalent one with
+// fewer commands
+func optimizeCmd(params []string) int {
+	fs := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table the optimized command file has to be equivalent on")
+	maxSteps := fs.Int("max-steps", equiv.DefaultMaxSteps, "give up the equivalence check once a run executed the number of commands")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot optimize [--size 5x5] [--max-steps 1000] commands.txt\n")
+		return 2
+	}
+
+	sizeX, sizeY, err := table.ParseSize(*size)
+	if err != nil {
+		fmt.Printf("invalid table size: %s\n", err.Error())
+		return 2
+	}
+
+	if *maxSteps <= 0 {
+		fmt.Printf("max steps must be positive\n")
+		return 2
+	}
+
+	prog, err := command.Load(args[0])
+	if err != nil {
+		fmt.Printf("failed to scan command list: %s\n", err.Error())
+		return 1
+	}
+
+	f, err := optimize.Optimize(prog, optimize.Config{SizeX: sizeX, SizeY: sizeY, MaxSteps: *maxSteps})
+	if err != nil {
+		fmt.Printf("failed to optimize command list: %s\n", err.Error())
+		return 1
+	}
+	os.Stdout.Write(command.Format(f))
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+// planCmd prints the command file taking the robot along the cheapest path
+// between two poses
+func planCmd(params []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table without blocked cells")
+	layoutFile := fs.String("layout", "", "file with rows of '.' free and '#' blocked cells, it overrides --size")
+	from := fs.String("from", "", "pose the robot starts at, e.g. 0,4,SOUTH")
+	to := fs.String("to", "", "pose the robot should end up at, e.g. 3,0,NORTH, the facing is optional")
+	moveCost := fs.Int("move-cost", plan.DefaultCost.Move, "cost of moving by a single step")
+	turnCost := fs.Int("turn-cost", plan.DefaultCost.Turn, "cost of turning left or right")
+	uturnCost := fs.Int("uturn-cost", plan.DefaultCost.UTurn, "cost of turning around")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+	if *from == "" || *to == "" {
+		fmt.Printf("missing start or goal pose\n")
+		fmt.Printf("expected usage: ./robot plan [--size 5x5|--layout table.txt] --from 0,4,SOUTH --to 3,0,NORTH\n")
+		return 2
+	}
+
+	layout, err := loadLayout(*size, *layoutFile)
+	if err != nil {
+		fmt.Printf("invalid table: %s\n", err.Error())
+		return 2
+	}
+	start, err := table.ParsePose(*from)
+	if err != nil {
+		fmt.Printf("invalid start pose: %s\n", err.Error())
+		return 2
+	}
+	goal, err := plan.ParseGoal(*to)
+	if err != nil {
+		fmt.Printf("invalid goal pose: %s\n", err.Error())
+		return 2
+	}
+
+	steps, err := plan.Plan(layout, start, goal, plan.Cost{Move: *moveCost, Turn: *turnCost, UTurn: *uturnCost})
+	if err != nil {
+		fmt.Printf("failed to plan: %s\n", err.Error())
+		return 1
+	}
+	os.Stdout.Write(plan.Source(start, steps))
+	return 0
+}
+
+// loadLayout returns the layout read from the file or the layout of the
+// table of the given size without blocked cells when there is no file
+func loadLayout(size string, layoutFile string) (table.Layout, error) {
+	if layoutFile != "" {
+		src, err := os.ReadFile(layoutFile)
+		if err != nil {
+			return table.Layout{}, err
+		}
+		return table.ParseLayout(src)
+	}
+
+	sizeX, sizeY, err := table.ParseSize(size)
+	if err != nil {
+		return table.Layout{}, err
+	}
+	return table.Layout{SizeX: sizeX, SizeY: sizeY}, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"robot/internal/jsonrpc"
+	"robot/internal/rpc"
+)
+
+// rpcCmd serves JSON-RPC 2.0 over stdin and stdout
+func rpcCmd(params []string) int {
+	fs := flag.NewFlagSet("rpc", flag.ContinueOnError)
+	framing := fs.String("framing", "line", "framing of messages: line for newline delimited JSON, header for Content-Length headers")
+	maxSteps := fs.Int("max-steps", rpc.DefaultMaxSteps, "stop a method after executing the number of commands, no limit when zero")
+	timeout := fs.Duration("timeout", rpc.DefaultTimeout, "stop a method after the duration, no limit when zero")
+	if err := parseFlags(fs, params); err != nil {
+		return 2
+	}
+	if *timeout < 0 || *maxSteps < 0 {
+		fmt.Fprintf(os.Stderr, "timeout and max steps can not be negative\n")
+		return 2
+	}
+
+	var codec jsonrpc.Codec
+	switch *framing {
+	case "line":
+		codec = jsonrpc.NewLineCodec(os.Stdin, os.Stdout)
+	case "header":
+		codec = jsonrpc.NewHeaderCodec(os.Stdin, os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid framing: '%s', expected line or header\n", *framing)
+		return 2
+	}
+
+	if err := rpc.New(rpc.WithMaxSteps(*maxSteps), rpc.WithTimeout(*timeout)).Serve(codec); err != nil {
+		fmt.Fprintf(os.Stderr, "rpc server failed: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"robot/internal/server"
+)
+
+// serveCmd serves the HTTP API controlling robots on tables created by clients
+func serveCmd(params []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	maxSteps := fs.Int("max-steps", server.DefaultMaxSteps, "stop a request after executing the number of commands, no limit when zero")
+	timeout := fs.Duration("timeout", server.DefaultTimeout, "stop a request after the duration, no limit when zero")
+	origins := fs.String("origins", "", "comma separated origins of pages allowed to open WebSocket connections in addition to the ones of the server, e.g. https://example.com, * allows any")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "too many arguments\n")
+		fmt.Fprintf(os.Stderr, "expected usage: ./robot serve [:8080] [--max-steps 1000] [--timeout 5s] [--origins https://example.com]\n")
+		return 2
+	}
+	addr := ":8080"
+	if len(args) == 1 {
+		addr = args[0]
+	}
+	if *timeout < 0 || *maxSteps < 0 {
+		fmt.Fprintf(os.Stderr, "timeout and max steps can not be negative\n")
+		return 2
+	}
+
+	opts := []server.Option{server.WithMaxSteps(*maxSteps), server.WithTimeout(*timeout)}
+	if *origins != "" {
+		for _, origin := range strings.Split(*origins, ",") {
+			opts = append(opts, server.WithOrigins(strings.TrimSpace(origin)))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "listening on %s\n", addr)
+	if err := http.ListenAndServe(addr, server.New(opts...)); err != nil {
+		fmt.Fprintf(os.Stderr, "server failed: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
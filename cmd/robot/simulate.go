@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"robot/internal/command"
+	"robot/internal/sim"
+)
+
+// simulateCmd runs command files of robots sharing a table against a discrete
+// clock and prints reports with their ticks followed by the cycle times
+func simulateCmd(params []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table without blocked cells")
+	layoutFile := fs.String("layout", "", "file with rows of '.' free and '#' blocked cells, it overrides --size")
+	moveTicks := fs.Int("move-ticks", sim.DefaultDurations.Move, "number of ticks of a single step")
+	turnTicks := fs.Int("turn-ticks", sim.DefaultDurations.Turn, "number of ticks of a turn by 90 degrees")
+	maxSteps := fs.Int("max-steps", sim.DefaultMaxSteps, "stop the simulation once a robot executed the number of commands, no limit when zero")
+	timeout := fs.Duration("timeout", 0, "stop the simulation after the duration, e.g. 5s, no limit when zero")
+	files, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot simulate [--size 5x5] [--layout table.txt] [--move-ticks 2] [--turn-ticks 1] [--max-steps 1000] [--timeout 5s] [name=]commands.txt...\n")
+		return 2
+	}
+	if *moveTicks < 0 || *turnTicks < 0 {
+		fmt.Printf("ticks can not be negative\n")
+		return 2
+	}
+	if *timeout < 0 || *maxSteps < 0 {
+		fmt.Printf("timeout and max steps can not be negative\n")
+		return 2
+	}
+
+	layout, err := loadLayout(*size, *layoutFile)
+	if err != nil {
+		fmt.Printf("invalid table: %s\n", err.Error())
+		return 2
+	}
+
+	s := sim.New(layout.SizeX, layout.SizeY,
+		sim.WithBlocked(layout.BlockedCells...),
+		sim.WithDurations(sim.Durations{Move: *moveTicks, Turn: *turnTicks}),
+		sim.WithMaxSteps(*maxSteps),
+	)
+	for _, arg := range files {
+		// robots are named after their files unless named explicitly
+		name, fileName := strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg)), arg
+		if i := strings.Index(arg, "="); i >= 0 {
+			name, fileName = arg[:i], arg[i+1:]
+		}
+
+		cmds, err := command.ScanCommandList(fileName)
+		if err != nil {
+			fmt.Printf("failed to scan command list: %s\n", err.Error())
+			return 1
+		}
+		if err := s.Add(name, cmds); err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return 2
+		}
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	res, err := s.RunContext(ctx)
+	if err != nil {
+		fmt.Printf("failed to simulate: %s\n", err.Error())
+		return 1
+	}
+	for _, stats := range res.Robots {
+		fmt.Printf("%s\n", stats)
+	}
+	fmt.Printf("finished after %d ticks\n", res.Ticks)
+	return 0
+}
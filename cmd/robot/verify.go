@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"robot/internal/analysis"
+	"robot/internal/command"
+	"robot/internal/table"
+)
+
+// poseList collects poses of a repeated flag
+type poseList []table.Pose
+
+func (l poseList) String() string {
+	poses := make([]string, 0, len(l))
+	for _, pose := range l {
+		poses = append(poses, pose.String())
+	}
+	return strings.Join(poses, " ")
+}
+
+func (l *poseList) Set(s string) error {
+	pose, err := table.ParsePose(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, pose)
+	return nil
+}
+
+// verifyCmd runs the command file from every start to check that none of its
+// statements is refused for leaving the table or entering a blocked cell, the
+// exit code is non zero when one may be
+func verifyCmd(params []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	size := fs.String("size", "5x5", "size of the table the command file is verified on")
+	layoutFile := fs.String("layout", "", "file with rows of '.' free and '#' blocked cells, it overrides --size")
+	poses := fs.Bool("poses", false, "list poses the robot may be at after each line")
+	maxSteps := fs.Int("max-steps", analysis.DefaultMaxSteps, "stop the run from a single start after executing the number of commands")
+	var starts poseList
+	fs.Var(&starts, "from", "pose the robot may start at, e.g. 0,0,NORTH, can be repeated and defaults to every pose on the table")
+	args, err := parseInterspersed(fs, params)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 1 {
+		fmt.Printf("missing file name from the argument list\n")
+		fmt.Printf("expected usage: ./robot verify [--size 5x5] [--layout table.txt] [--from 0,0,NORTH]... [--poses] [--max-steps 1000] commands.txt\n")
+		return 2
+	}
+
+	if *maxSteps <= 0 {
+		fmt.Printf("max steps must be positive\n")
+		return 2
+	}
+
+	layout, err := loadLayout(*size, *layoutFile)
+	if err != nil {
+		fmt.Printf("invalid table: %s\n", err.Error())
+		return 2
+	}
+
+	prog, err := command.Load(args[0])
+	if err != nil {
+		fmt.Printf("failed to scan command list: %s\n", err.Error())
+		return 1
+	}
+
+	res, err := analysis.Simulate(prog, analysis.Config{
+		SizeX:    layout.SizeX,
+		SizeY:    layout.SizeY,
+		Blocked:  layout.BlockedCells,
+		Starts:   starts,
+		MaxSteps: *maxSteps,
+	})
+	if err != nil {
+		fmt.Printf("failed to analyze command list: %s\n", err.Error())
+		return 1
+	}
+
+	if *poses {
+		positions := make([]command.Pos, 0, len(res.Poses))
+		for pos := range res.Poses {
+			positions = append(positions, pos)
+		}
+		sort.Slice(positions, func(i, j int) bool {
+			if positions[i].File != positions[j].File {
+				return positions[i].File < positions[j].File
+			}
+			return positions[i].Line < positions[j].Line
+		})
+		for _, pos := range positions {
+			fmt.Printf("%s: %s\n", pos, poseList(res.Poses[pos]).String())
+		}
+	}
+
+	for _, r := range res.Refusals {
+		fmt.Printf("%s\n", r)
+	}
+	for _, f := range res.Failures {
+		fmt.Printf("%s\n", f)
+	}
+	if !res.Safe() {
+		return 1
+	}
+	return 0
+}
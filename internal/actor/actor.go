@@ -0,0 +1,381 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// DefaultMailboxSize is the number of commands an actor mailbox holds unless
+// `WithMailboxSize` is given
+const DefaultMailboxSize = 16
+
+// DefaultMaxSteps is the number of commands a command sent to an actor may
+// execute, e.g. in loops, unless `WithMaxSteps` is given
+const DefaultMaxSteps = 1000000
+
+// Runtime runs robots sharing a table, every robot is an actor consuming
+// commands sent to its mailbox. Commands of different actors never run at the
+// same time and cells taken by a robot are blocked for the other ones, so
+// moves into them are refused like moves into blocked cells
+type Runtime struct {
+	sizeX         uint
+	sizeY         uint
+	blocked       []point.Point
+	output        io.Writer
+	mailboxSize   int
+	maxSteps      int
+	deterministic bool
+
+	// world serialises commands and guards occupied
+	world    sync.Mutex
+	occupied map[point.Point]*Actor
+
+	mu      sync.Mutex
+	actors  []*Actor
+	names   map[string]bool
+	started bool
+}
+
+// Actor is a robot of the runtime, it executes commands sent to it in order
+type Actor struct {
+	name    string
+	rt      *Runtime
+	table   *table.Table
+	env     *command.Env
+	pos     *point.Point
+	mailbox chan command.Command
+	// stopped is closed once the actor consumes no more commands
+	stopped chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Runtime)
+
+// WithMailboxSize provides an option to change the number of commands a
+// mailbox holds before sending blocks
+func WithMailboxSize(n int) Option {
+	return func(r *Runtime) {
+		r.mailboxSize = n
+	}
+}
+
+// WithMaxSteps provides an option to stop the run once a command sent to an
+// actor executed the number of commands, zero leaves commands unlimited
+func WithMaxSteps(n int) Option {
+	return func(r *Runtime) {
+		r.maxSteps = n
+	}
+}
+
+// WithDeterministic provides an option to run actors one command at a time in
+// the order they were spawned instead of concurrently. Results then only
+// depend on the commands sent to each actor and not on the timing of sends,
+// but every actor has to be closed for the run to end
+func WithDeterministic() Option {
+	return func(r *Runtime) {
+		r.deterministic = true
+	}
+}
+
+// WithBlocked provides an option to block cells of the shared table
+func WithBlocked(cells ...point.Point) Option {
+	return func(r *Runtime) {
+		r.blocked = append(r.blocked, cells...)
+	}
+}
+
+// WithReportOutput provides an option to specify custom output for reports,
+// every report is prefixed by the name of the actor
+func WithReportOutput(out io.Writer) Option {
+	return func(r *Runtime) {
+		r.output = out
+	}
+}
+
+func New(sizeX, sizeY uint, opts ...Option) *Runtime {
+	r := &Runtime{
+		sizeX:       sizeX,
+		sizeY:       sizeY,
+		output:      os.Stdout,
+		mailboxSize: DefaultMailboxSize,
+		maxSteps:    DefaultMaxSteps,
+		occupied:    map[point.Point]*Actor{},
+		names:       map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Spawn adds an unplaced robot to the runtime, actors can only be spawned
+// before the runtime is run
+func (r *Runtime) Spawn(name string) (*Actor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return nil, ErrAlreadyStarted
+	}
+	if r.names[name] {
+		return nil, fmt.Errorf("%w: '%s'", ErrDuplicateActor, name)
+	}
+
+	a := &Actor{
+		name:    name,
+		rt:      r,
+		mailbox: make(chan command.Command, r.mailboxSize),
+		stopped: make(chan struct{}),
+	}
+	a.table = table.New(r.sizeX, r.sizeY,
+		table.WithBlocked(r.blocked...),
+		table.WithBlockedFunc(func(pos point.Point) bool {
+			other, ok := r.occupied[pos]
+			return ok && other != a
+		}),
+		table.WithEventHook(func(e table.Event) {
+			if e.Kind == table.EventPlaced || e.Kind == table.EventMoved {
+				r.occupy(a, e.Robot.Pos)
+			}
+		}),
+		table.WithReportOutput(&prefixWriter{prefix: name + ": ", w: r.output}),
+	)
+	r.actors = append(r.actors, a)
+	r.names[name] = true
+	return a, nil
+}
+
+// occupy moves the actor to the cell, the world lock has to be held
+func (r *Runtime) occupy(a *Actor, pos point.Point) {
+	if a.pos != nil {
+		delete(r.occupied, *a.pos)
+	}
+	r.occupied[pos] = a
+	a.pos = &pos
+}
+
+// Run executes commands sent to the actors until every actor was closed and
+// its mailbox drained, an actor halted or the context is done. The context is
+// also checked before every command of loops and procedures. The first failure
+// of a command other than a refusal, e.g. an exhausted step budget, stops every
+// actor and is returned
+func (r *Runtime) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.started {
+		r.mu.Unlock()
+		return ErrAlreadyStarted
+	}
+	r.started = true
+	actors := r.actors
+	r.mu.Unlock()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, a := range actors {
+		a.env = command.NewEnvContext(runCtx, command.WithMaxSteps(r.maxSteps))
+	}
+
+	var err error
+	if r.deterministic {
+		err = r.runSerial(runCtx, actors)
+	} else {
+		err = r.runParallel(runCtx, cancel, actors)
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// runParallel runs every actor in a goroutine of its own
+func (r *Runtime) runParallel(ctx context.Context, cancel context.CancelFunc, actors []*Actor) error {
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for _, a := range actors {
+		wg.Add(1)
+		go func(a *Actor) {
+			defer wg.Done()
+			defer close(a.stopped)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case cmd, ok := <-a.mailbox:
+					if !ok || ctx.Err() != nil {
+						return
+					}
+					halt, err := a.exec(ctx, cmd)
+					if err != nil {
+						once.Do(func() {
+							first = err
+							cancel()
+						})
+					}
+					if err != nil || halt {
+						return
+					}
+				}
+			}
+		}(a)
+	}
+	wg.Wait()
+	return first
+}
+
+// runSerial runs a command of every actor in turn, it waits for the next
+// command of an actor until the actor is closed
+func (r *Runtime) runSerial(ctx context.Context, actors []*Actor) error {
+	live := append([]*Actor(nil), actors...)
+	defer func() {
+		for _, a := range live {
+			close(a.stopped)
+		}
+	}()
+
+	for len(live) > 0 {
+		for i := 0; i < len(live); {
+			a := live[i]
+			var (
+				cmd command.Command
+				ok  bool
+			)
+			select {
+			case <-ctx.Done():
+				return nil
+			case cmd, ok = <-a.mailbox:
+			}
+
+			stop := !ok
+			if ok {
+				halt, err := a.exec(ctx, cmd)
+				if err != nil {
+					return err
+				}
+				stop = halt
+			}
+			if stop {
+				close(a.stopped)
+				live = append(live[:i], live[i+1:]...)
+				continue
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// exec executes the command while no other actor runs, refusals are ignored
+// and halt is set when the actor should stop. A command stopped by the context
+// halts the actor, the run returns the error of the context
+func (a *Actor) exec(ctx context.Context, cmd command.Command) (halt bool, err error) {
+	a.rt.world.Lock()
+	defer a.rt.world.Unlock()
+
+	err = a.env.Exec(a.table, cmd)
+	switch {
+	case errors.Is(err, command.ErrHalt):
+		return true, nil
+	case err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		return true, nil
+	case err != nil && !table.IsRefusal(err):
+		return false, fmt.Errorf("%s: %w", a.name, err)
+	}
+	return false, nil
+}
+
+// Name returns the name the actor was spawned with
+func (a *Actor) Name() string {
+	return a.name
+}
+
+// Robot returns the robot position and facing
+func (a *Actor) Robot() (point.Point, direction.Direction, error) {
+	a.rt.world.Lock()
+	defer a.rt.world.Unlock()
+	return a.table.Robot()
+}
+
+// Send puts the command into the mailbox, it blocks while the mailbox is full
+// until the actor takes a command, the actor stops or the context is done
+func (a *Actor) Send(ctx context.Context, cmd command.Command) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrActorClosed
+	}
+
+	select {
+	case <-a.stopped:
+		return ErrActorStopped
+	default:
+	}
+	select {
+	case a.mailbox <- cmd:
+		return nil
+	case <-a.stopped:
+		return ErrActorStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend puts the command into the mailbox unless it is full
+func (a *Actor) TrySend(cmd command.Command) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrActorClosed
+	}
+
+	select {
+	case <-a.stopped:
+		return ErrActorStopped
+	default:
+	}
+	select {
+	case a.mailbox <- cmd:
+		return nil
+	default:
+		return ErrMailboxFull
+	}
+}
+
+// Close tells the actor no more commands are coming, it stops once it has
+// executed the commands already in the mailbox
+func (a *Actor) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.closed {
+		a.closed = true
+		close(a.mailbox)
+	}
+}
+
+// prefixWriter prefixes every write, reports are written by a single write
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, p.prefix+string(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
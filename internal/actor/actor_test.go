@@ -0,0 +1,318 @@
+package actor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/actor"
+	"robot/internal/command"
+	"robot/internal/point"
+)
+
+func parse(t *testing.T, srcs ...string) []command.Command {
+	t.Helper()
+
+	cmds := make([]command.Command, 0, len(srcs))
+	for _, src := range srcs {
+		cmd, err := command.DefaultRegistry.Parse(src)
+		require.NoError(t, err)
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// feed sends the commands to the actor and closes it
+func feed(ctx context.Context, a *actor.Actor, cmds []command.Command) error {
+	defer a.Close()
+	for _, cmd := range cmds {
+		if err := a.Send(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		sizeX    uint
+		sizeY    uint
+		scripts  map[string][]string
+		order    []string
+		expected string
+	}{
+		{
+			name:  "should refuse moves into cells taken by other robots",
+			sizeX: 3,
+			sizeY: 1,
+			scripts: map[string][]string{
+				"a": {"PLACE 0,0,EAST", "MOVE", "MOVE", "REPORT"},
+				"b": {"PLACE 2,0,WEST", "MOVE", "MOVE", "REPORT"},
+			},
+			order:    []string{"a", "b"},
+			expected: "a: Robot position: (1, 0) facing: EAST\nb: Robot position: (2, 0) facing: WEST\n",
+		},
+		{
+			name:  "should give the cell to the robot spawned first",
+			sizeX: 3,
+			sizeY: 1,
+			scripts: map[string][]string{
+				"a": {"PLACE 0,0,EAST", "MOVE", "MOVE", "REPORT"},
+				"b": {"PLACE 2,0,WEST", "MOVE", "MOVE", "REPORT"},
+			},
+			order:    []string{"b", "a"},
+			expected: "b: Robot position: (1, 0) facing: WEST\na: Robot position: (0, 0) facing: EAST\n",
+		},
+		{
+			name:  "should run the remaining robots after one halts",
+			sizeX: 5,
+			sizeY: 5,
+			scripts: map[string][]string{
+				"a": {"PLACE 0,0,NORTH", "HALT", "REPORT"},
+				"b": {"PLACE 0,1,EAST", "MOVE 2", "LEFT", "REPORT"},
+			},
+			order:    []string{"a", "b"},
+			expected: "b: Robot position: (2, 1) facing: NORTH\n",
+		},
+		{
+			name:  "should not place a robot on a taken cell",
+			sizeX: 5,
+			sizeY: 5,
+			scripts: map[string][]string{
+				"a": {"PLACE 1,1,NORTH", "REPORT"},
+				"b": {"PLACE 1,1,SOUTH", "REPORT"},
+			},
+			order:    []string{"a", "b"},
+			expected: "a: Robot position: (1, 1) facing: NORTH\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// repeated runs have to give the same output regardless of the
+			// timing of sends
+			for i := 0; i < 20; i++ {
+				out := &bytes.Buffer{}
+				rt := actor.New(tt.sizeX, tt.sizeY, actor.WithDeterministic(), actor.WithMailboxSize(1), actor.WithReportOutput(out))
+
+				ctx := context.Background()
+				var wg sync.WaitGroup
+				for _, name := range tt.order {
+					a, err := rt.Spawn(name)
+					require.NoError(t, err)
+
+					cmds := parse(t, tt.scripts[name]...)
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						feed(ctx, a, cmds)
+					}()
+				}
+
+				require.NoError(t, rt.Run(ctx))
+				wg.Wait()
+				require.Equal(t, tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const robots = 8
+
+	rt := actor.New(robots, robots, actor.WithReportOutput(&bytes.Buffer{}), actor.WithMailboxSize(2))
+	ctx := context.Background()
+
+	actors := make([]*actor.Actor, robots)
+	errs := make(chan error, robots)
+	// robots only start moving once every robot is placed, otherwise one of
+	// them may take the cell of a robot that is not placed yet
+	placed := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range actors {
+		a, err := rt.Spawn(fmt.Sprintf("r%d", i))
+		require.NoError(t, err)
+		actors[i] = a
+		require.NoError(t, a.Send(ctx, parse(t, fmt.Sprintf("PLACE %d,%d,NORTH", i, i))[0]))
+
+		srcs := []string{}
+		for j := 0; j < 50; j++ {
+			srcs = append(srcs, "MOVE", "MOVE 2", "LEFT", "MOVE", "BACK", "RIGHT", "REPORT")
+		}
+		cmds := parse(t, srcs...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-placed
+			errs <- feed(ctx, a, cmds)
+		}()
+	}
+	go func() {
+		defer close(placed)
+		for _, a := range actors {
+			for {
+				if _, _, err := a.Robot(); err == nil {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	require.NoError(t, rt.Run(ctx))
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	taken := map[point.Point]string{}
+	for _, a := range actors {
+		pos, _, err := a.Robot()
+		require.NoError(t, err)
+		other, ok := taken[pos]
+		require.False(t, ok, "%s and %s share %v", a.Name(), other, pos)
+		taken[pos] = a.Name()
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	for _, deterministic := range []bool{false, true} {
+		opts := []actor.Option{actor.WithReportOutput(&bytes.Buffer{})}
+		if deterministic {
+			opts = append(opts, actor.WithDeterministic())
+		}
+		rt := actor.New(5, 5, opts...)
+		a, err := rt.Spawn("a")
+		require.NoError(t, err)
+		require.NoError(t, a.Send(context.Background(), parse(t, "PLACE 0,0,NORTH")[0]))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- rt.Run(ctx)
+		}()
+
+		// the actor waits for commands until the run is cancelled
+		require.Eventually(t, func() bool {
+			_, _, err := a.Robot()
+			return err == nil
+		}, time.Second, time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		require.ErrorIs(t, a.Send(context.Background(), parse(t, "MOVE")[0]), actor.ErrActorStopped)
+		require.ErrorIs(t, rt.Run(context.Background()), actor.ErrAlreadyStarted)
+	}
+}
+
+// spin returns a single command turning the robot for a long time
+func spin(t *testing.T, count int) command.Command {
+	t.Helper()
+
+	cmds, err := command.ScanCommandList("spin.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+		return []byte(fmt.Sprintf("REPEAT %d\nLEFT\nEND\n", count)), nil
+	}))
+	require.NoError(t, err)
+	require.Len(t, cmds, 1)
+	return cmds[0]
+}
+
+func TestCancelLoop(t *testing.T) {
+	t.Parallel()
+
+	for _, deterministic := range []bool{false, true} {
+		opts := []actor.Option{actor.WithReportOutput(&bytes.Buffer{}), actor.WithMaxSteps(0)}
+		if deterministic {
+			opts = append(opts, actor.WithDeterministic())
+		}
+		rt := actor.New(5, 5, opts...)
+		a, err := rt.Spawn("a")
+		require.NoError(t, err)
+		require.NoError(t, feed(context.Background(), a, append(parse(t, "PLACE 0,0,NORTH"), spin(t, 1000000000))))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, rt.Run(ctx), context.DeadlineExceeded)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	rt := actor.New(5, 5, actor.WithReportOutput(&bytes.Buffer{}), actor.WithMaxSteps(10))
+	a, err := rt.Spawn("a")
+	require.NoError(t, err)
+	// the budget applies to each command sent
+	require.NoError(t, feed(context.Background(), a, append(parse(t, "PLACE 0,0,NORTH"), spin(t, 9), spin(t, 9), spin(t, 10))))
+
+	err = rt.Run(context.Background())
+	require.ErrorIs(t, err, command.ErrMaxSteps)
+	require.EqualError(t, err, "a: spin.txt:1: step budget exhausted")
+}
+
+func TestBackPressure(t *testing.T) {
+	t.Parallel()
+
+	rt := actor.New(5, 5, actor.WithMailboxSize(2), actor.WithReportOutput(&bytes.Buffer{}))
+	a, err := rt.Spawn("a")
+	require.NoError(t, err)
+	move := parse(t, "MOVE")[0]
+
+	require.NoError(t, a.TrySend(move))
+	require.NoError(t, a.TrySend(move))
+	require.ErrorIs(t, a.TrySend(move), actor.ErrMailboxFull)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, a.Send(ctx, move), context.DeadlineExceeded)
+
+	a.Close()
+	require.ErrorIs(t, a.Send(context.Background(), move), actor.ErrActorClosed)
+	require.ErrorIs(t, a.TrySend(move), actor.ErrActorClosed)
+	a.Close()
+}
+
+func TestFailure(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	rt := actor.New(5, 5, actor.WithReportOutput(&bytes.Buffer{}))
+	a, err := rt.Spawn("a")
+	require.NoError(t, err)
+	b, err := rt.Spawn("b")
+	require.NoError(t, err)
+
+	_, err = rt.Spawn("a")
+	require.ErrorIs(t, err, actor.ErrDuplicateActor)
+
+	require.NoError(t, a.Send(context.Background(), func(t command.Table, env *command.Env) error {
+		return errBoom
+	}))
+	a.Close()
+
+	// b is never closed and is stopped by the failure of a
+	err = rt.Run(context.Background())
+	require.ErrorIs(t, err, errBoom)
+	require.EqualError(t, err, "a: boom")
+	require.ErrorIs(t, b.Send(context.Background(), parse(t, "MOVE")[0]), actor.ErrActorStopped)
+
+	_, err = rt.Spawn("c")
+	require.ErrorIs(t, err, actor.ErrAlreadyStarted)
+}
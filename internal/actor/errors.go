@@ -0,0 +1,11 @@
+package actor
+
+import "errors"
+
+var (
+	ErrAlreadyStarted error = errors.New("runtime already started")
+	ErrDuplicateActor error = errors.New("duplicate actor")
+	ErrActorClosed    error = errors.New("actor closed")
+	ErrActorStopped   error = errors.New("actor stopped")
+	ErrMailboxFull    error = errors.New("mailbox full")
+)
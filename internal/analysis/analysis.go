@@ -0,0 +1,226 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// DefaultMaxSteps is the step budget of a run from a single start when none is
+// configured
+const DefaultMaxSteps = 100000
+
+// movements are commands that may be refused for moving the robot off the
+// table or into a blocked cell, refusals of other commands such as PLACE are
+// not reported
+var movements = map[string]bool{
+	"MOVE":  true,
+	"BACK":  true,
+	"GOTO":  true,
+	"COVER": true,
+}
+
+// Config holds the table and the poses the program is simulated from
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// Blocked are cells of the table the robot can not enter
+	Blocked []point.Point
+	// Starts are poses the robot may be placed at before the program runs,
+	// every pose on a free cell of the table is assumed when empty
+	Starts []table.Pose
+	// MaxSteps limits the number of commands executed by the run from a
+	// single start, DefaultMaxSteps is used when zero
+	MaxSteps int
+}
+
+// Refusal is a statement the table may refuse as the robot would end up
+// outside of it or in a blocked cell
+type Refusal struct {
+	Pos command.Pos
+	// Name is the command of the statement
+	Name string
+	// Err is the reason of the refusal, table.ErrEndingPositionOutOfBounds or
+	// table.ErrPositionBlocked
+	Err error
+	// Starts are the poses starting at which the statement is refused
+	Starts []table.Pose
+}
+
+// String returns a string representation of Refusal
+func (r Refusal) String() string {
+	starts := make([]string, 0, len(r.Starts))
+	for _, start := range r.Starts {
+		starts = append(starts, start.String())
+	}
+	reason := "leave the table"
+	if errors.Is(r.Err, table.ErrPositionBlocked) {
+		reason = "enter a blocked cell"
+	}
+	return fmt.Sprintf("%s: %s may %s when starting at %s", r.Pos, r.Name, reason, strings.Join(starts, "; "))
+}
+
+// refusalKey tells refusals of a statement apart by their reason
+type refusalKey struct {
+	pos     command.Pos
+	blocked bool
+}
+
+// Failure is a run that did not complete, e.g. as it exhausted the step budget,
+// poses it did not reach are missing from the result
+type Failure struct {
+	Start table.Pose
+	Err   error
+}
+
+// String returns a string representation of Failure
+func (f Failure) String() string {
+	return fmt.Sprintf("run starting at %s failed: %s", f.Start, f.Err)
+}
+
+// Result holds what the program may do on the table
+type Result struct {
+	// Poses maps positions of statements to the poses the robot may be at
+	// once they were executed, statements executed before the robot is
+	// placed and statements that are never executed are missing
+	Poses map[command.Pos][]table.Pose
+	// Refusals are sorted by position
+	Refusals []Refusal
+	// Failures are runs that did not complete in the order of their starts
+	Failures []Failure
+}
+
+// Safe reports whether none of the statements can be refused, a result with
+// failed runs is never proved safe
+func (r *Result) Safe() bool {
+	return len(r.Refusals) == 0 && len(r.Failures) == 0
+}
+
+// Simulate computes poses reachable by every statement of the loaded program
+// and the movements that may be refused for leaving the table or entering a
+// blocked cell. It is an exhaustive simulation rather than an abstract
+// interpretation, the program is run once from every start and as programs
+// are deterministic the result is exact for the starts. Its cost grows with
+// the number of starts times the length of the runs. Runs failing or
+// exhausting the step budget are reported as failures
+func Simulate(prog *command.Program, cfg Config) (*Result, error) {
+	names := map[command.Pos]string{}
+	prog.Walk(func(n *command.Node) {
+		names[n.Pos] = n.Name
+	})
+
+	starts := cfg.Starts
+	if len(starts) == 0 {
+		blocked := map[point.Point]bool{}
+		for _, cell := range cfg.Blocked {
+			blocked[cell] = true
+		}
+		for _, start := range table.Poses(cfg.SizeX, cfg.SizeY) {
+			if !blocked[start.Pos] {
+				starts = append(starts, start)
+			}
+		}
+	}
+
+	maxSteps := cfg.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	res := &Result{
+		Poses: map[command.Pos][]table.Pose{},
+	}
+	poses := map[command.Pos]map[table.Pose]bool{}
+	refusals := map[refusalKey]*Refusal{}
+	for _, start := range starts {
+		tbl := table.New(cfg.SizeX, cfg.SizeY, table.WithBlocked(cfg.Blocked...), table.WithReportOutput(io.Discard))
+		if err := tbl.PlaceRobot(start.Pos, start.Facing); err != nil {
+			return nil, fmt.Errorf("invalid start %s: %w", start, err)
+		}
+
+		hook := command.WithStepHook(func(pos command.Pos, t command.Table, err error) {
+			blocked := errors.Is(err, table.ErrPositionBlocked)
+			if (blocked || errors.Is(err, table.ErrEndingPositionOutOfBounds)) && movements[names[pos]] {
+				key := refusalKey{pos: pos, blocked: blocked}
+				r, ok := refusals[key]
+				if !ok {
+					r = &Refusal{Pos: pos, Name: names[pos], Err: table.ErrEndingPositionOutOfBounds}
+					if blocked {
+						r.Err = table.ErrPositionBlocked
+					}
+					refusals[key] = r
+				}
+				if len(r.Starts) == 0 || r.Starts[len(r.Starts)-1] != start {
+					r.Starts = append(r.Starts, start)
+				}
+			}
+			if errors.Is(err, command.ErrMaxSteps) {
+				return
+			}
+
+			p, facing, err := t.Robot()
+			if err != nil {
+				return
+			}
+			if poses[pos] == nil {
+				poses[pos] = map[table.Pose]bool{}
+			}
+			poses[pos][table.Pose{Pos: p, Facing: facing}] = true
+		})
+		if err := command.Run(tbl, prog.Cmds, hook, command.WithMaxSteps(maxSteps)); err != nil {
+			res.Failures = append(res.Failures, Failure{Start: start, Err: err})
+		}
+	}
+
+	for pos, set := range poses {
+		for pose := range set {
+			res.Poses[pos] = append(res.Poses[pos], pose)
+		}
+		sortPoses(res.Poses[pos])
+	}
+	for _, r := range refusals {
+		res.Refusals = append(res.Refusals, *r)
+	}
+	sort.Slice(res.Refusals, func(i, j int) bool {
+		a, b := res.Refusals[i], res.Refusals[j]
+		if a.Pos != b.Pos {
+			return lessPos(a.Pos, b.Pos)
+		}
+		// leaving the table is reported before entering a blocked cell
+		return !errors.Is(a.Err, table.ErrPositionBlocked)
+	})
+	return res, nil
+}
+
+// lessPos orders positions by file and line
+func lessPos(a, b command.Pos) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	return a.Line < b.Line
+}
+
+// sortPoses orders poses the same way as `table.Poses`
+func sortPoses(poses []table.Pose) {
+	facings := map[direction.Direction]int{}
+	for i, d := range direction.All() {
+		facings[d] = i
+	}
+	sort.Slice(poses, func(i, j int) bool {
+		a, b := poses[i], poses[j]
+		if a.Pos.Y != b.Pos.Y {
+			return a.Pos.Y < b.Pos.Y
+		}
+		if a.Pos.X != b.Pos.X {
+			return a.Pos.X < b.Pos.X
+		}
+		return facings[a.Facing] < facings[b.Facing]
+	})
+}
@@ -0,0 +1,195 @@
+package analysis_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/analysis"
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name             string
+		files            map[string]string
+		blocked          []point.Point
+		starts           []table.Pose
+		maxSteps         int
+		expectedPoses    map[int][]string
+		expectedRefusals []string
+		expectedFailures []string
+	}{
+		{
+			name: "should prove program placing the robot safe",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nMOVE 2\nRIGHT\nMOVE\nREPORT\n",
+			},
+			expectedPoses: map[int][]string{
+				1: {"0,0,NORTH"},
+				2: {"0,2,NORTH"},
+				3: {"0,2,EAST"},
+				4: {"1,2,EAST"},
+				5: {"1,2,EAST"},
+			},
+			expectedRefusals: []string{},
+		},
+		{
+			name: "should report moves refused from the given starts",
+			files: map[string]string{
+				"main.txt": "MOVE 2\nLEFT\nMOVE\n",
+			},
+			starts: []table.Pose{
+				{Pos: point.Point{X: 0, Y: 0}, Facing: direction.North},
+				{Pos: point.Point{X: 1, Y: 2}, Facing: direction.East},
+			},
+			expectedPoses: map[int][]string{
+				1: {"0,2,NORTH", "1,2,EAST"},
+				2: {"0,2,WEST", "1,2,NORTH"},
+				3: {"0,2,WEST", "1,2,NORTH"},
+			},
+			expectedRefusals: []string{
+				"main.txt:1: MOVE may leave the table when starting at 1,2,EAST",
+				"main.txt:3: MOVE may leave the table when starting at 0,0,NORTH; 1,2,EAST",
+			},
+		},
+		{
+			name: "should report refusals of procedures at their statements",
+			files: map[string]string{
+				"main.txt": "DEF P\n  MOVE 1,PARTIAL\n  BACK 2\nEND\nCALL P\nCALL P\n",
+			},
+			starts: []table.Pose{
+				{Pos: point.Point{X: 1, Y: 1}, Facing: direction.South},
+			},
+			expectedPoses: map[int][]string{
+				2: {"1,0,SOUTH", "1,1,SOUTH"},
+				3: {"1,1,SOUTH", "1,2,SOUTH"},
+				5: {"1,2,SOUTH"},
+				6: {"1,1,SOUTH"},
+			},
+			expectedRefusals: []string{
+				"main.txt:3: BACK may leave the table when starting at 1,1,SOUTH",
+			},
+		},
+		{
+			name: "should not report refused placements",
+			files: map[string]string{
+				"main.txt": "PLACE 5,5,NORTH\nMOVE\n",
+			},
+			starts: []table.Pose{
+				{Pos: point.Point{X: 0, Y: 2}, Facing: direction.North},
+			},
+			expectedPoses: map[int][]string{
+				1: {"0,2,NORTH"},
+				2: {"0,2,NORTH"},
+			},
+			expectedRefusals: []string{
+				"main.txt:2: MOVE may leave the table when starting at 0,2,NORTH",
+			},
+		},
+		{
+			name: "should report moves into blocked cells",
+			files: map[string]string{
+				"main.txt": "MOVE\nGOTO 1,1\nGOTO 3,0\n",
+			},
+			blocked: []point.Point{{X: 1, Y: 1}},
+			starts: []table.Pose{
+				{Pos: point.Point{X: 1, Y: 0}, Facing: direction.North},
+				{Pos: point.Point{X: 0, Y: 2}, Facing: direction.North},
+			},
+			expectedPoses: map[int][]string{
+				1: {"1,0,NORTH", "0,2,NORTH"},
+				2: {"1,0,NORTH", "0,2,NORTH"},
+				3: {"1,0,NORTH", "0,2,NORTH"},
+			},
+			expectedRefusals: []string{
+				"main.txt:1: MOVE may leave the table when starting at 0,2,NORTH",
+				"main.txt:1: MOVE may enter a blocked cell when starting at 1,0,NORTH",
+				"main.txt:2: GOTO may enter a blocked cell when starting at 1,0,NORTH; 0,2,NORTH",
+				"main.txt:3: GOTO may leave the table when starting at 1,0,NORTH; 0,2,NORTH",
+			},
+		},
+		{
+			name: "should start on free cells only",
+			files: map[string]string{
+				"main.txt": "GOTO 0,0,NORTH\nCOVER\n",
+			},
+			blocked: []point.Point{{X: 1, Y: 1}},
+			expectedPoses: map[int][]string{
+				1: {"0,0,NORTH"},
+				2: {"0,1,SOUTH"},
+			},
+			expectedRefusals: []string{},
+		},
+		{
+			name: "should report runs exhausting the step budget",
+			files: map[string]string{
+				"main.txt": "REPEAT 1000\n  LEFT\nEND\n",
+			},
+			starts: []table.Pose{
+				{Pos: point.Point{X: 0, Y: 0}, Facing: direction.North},
+				{Pos: point.Point{X: 1, Y: 1}, Facing: direction.North},
+			},
+			maxSteps: 3,
+			expectedPoses: map[int][]string{
+				2: {"0,0,WEST", "0,0,SOUTH", "1,1,WEST", "1,1,SOUTH"},
+			},
+			expectedRefusals: []string{},
+			expectedFailures: []string{
+				"run starting at 0,0,NORTH failed: stopped at main.txt:2 after 3 steps with robot at 0,0,SOUTH: step budget exhausted",
+				"run starting at 1,1,NORTH failed: stopped at main.txt:2 after 3 steps with robot at 1,1,SOUTH: step budget exhausted",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			readFile := func(fileName string) ([]byte, error) {
+				src, ok := tt.files[fileName]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", os.ErrNotExist, fileName)
+				}
+				return []byte(src), nil
+			}
+			prog, err := command.Load("main.txt", command.WithReadFile(readFile))
+			require.NoError(t, err)
+
+			res, err := analysis.Simulate(prog, analysis.Config{SizeX: 3, SizeY: 3, Blocked: tt.blocked, Starts: tt.starts, MaxSteps: tt.maxSteps})
+			require.NoError(t, err)
+
+			poses := map[int][]string{}
+			for pos, states := range res.Poses {
+				for _, pose := range states {
+					poses[pos.Line] = append(poses[pos.Line], pose.String())
+				}
+			}
+			require.Equal(t, tt.expectedPoses, poses)
+
+			refusals := []string{}
+			for _, r := range res.Refusals {
+				refusals = append(refusals, r.String())
+			}
+			require.Equal(t, tt.expectedRefusals, refusals)
+
+			failures := []string{}
+			for _, f := range res.Failures {
+				failures = append(failures, f.String())
+			}
+			if tt.expectedFailures == nil {
+				tt.expectedFailures = []string{}
+			}
+			require.Equal(t, tt.expectedFailures, failures)
+			require.Equal(t, len(tt.expectedRefusals) == 0 && len(tt.expectedFailures) == 0, res.Safe())
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package command
+
+import (
+	"fmt"
+
+	"robot/internal/cover"
+	"robot/internal/direction"
+	"robot/internal/explore"
+	"robot/internal/expr"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// stepsArgs are arguments of the commands moving the robot by several steps
+var stepsArgs = []Arg{
+	{Name: "steps", Kind: ArgExpr, Optional: true},
+	{Name: "mode", Kind: ArgKeyword, Choices: []string{"PARTIAL"}, Optional: true},
+}
+
+// builtinSpecs are commands every registry starts with
+var builtinSpecs = []Spec{
+	{
+		Name: "PLACE",
+		Args: []Arg{
+			{Name: "x", Kind: ArgExpr},
+			{Name: "y", Kind: ArgExpr},
+			{Name: "facing", Kind: ArgDirection},
+		},
+		Help: "places the robot on the table at the given position and facing",
+		Exec: func(t Table, env *Env, args []Value) error {
+			x, err := env.Eval(t, args[0].Expr)
+			if err != nil {
+				return err
+			}
+			y, err := env.Eval(t, args[1].Expr)
+			if err != nil {
+				return err
+			}
+			return t.PlaceRobot(point.Point{X: x, Y: y}, args[2].Direction)
+		},
+	},
+	{
+		Name: "SET",
+		Args: []Arg{
+			{Name: "assignment", Kind: ArgAssignment},
+		},
+		Help: "assigns value of the expression to the variable",
+		Exec: func(t Table, env *Env, args []Value) error {
+			v, err := env.Eval(t, args[0].Expr)
+			if err != nil {
+				return err
+			}
+			env.Set(args[0].Name, v)
+			return nil
+		},
+	},
+	{
+		Name:           "MOVE",
+		Args:           stepsArgs,
+		Help:           "moves the robot forward by one or the given number of steps, PARTIAL mode stops at the edge instead of refusing the move",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			if len(args) == 0 {
+				_, err := t.MoveRobot()
+				return err
+			}
+			return moveBy(t, env, args, 1)
+		},
+	},
+	{
+		Name:           "BACK",
+		Args:           stepsArgs,
+		Help:           "moves the robot backward by one or the given number of steps without turning it",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			if len(args) == 0 {
+				_, err := t.MoveRobotBy(-1, false)
+				return err
+			}
+			return moveBy(t, env, args, -1)
+		},
+	},
+	{
+		Name:           "LEFT",
+		Help:           "rotates the robot by 90 degrees counterclockwise",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			_, err := t.RotateRobot(true)
+			return err
+		},
+	},
+	{
+		Name:           "RIGHT",
+		Help:           "rotates the robot by 90 degrees clockwise",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			_, err := t.RotateRobot(false)
+			return err
+		},
+	},
+	{
+		Name:           "UTURN",
+		Help:           "turns the robot around",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			_, err := t.TurnRobot(180)
+			return err
+		},
+	},
+	{
+		Name: "TURN",
+		Args: []Arg{
+			{Name: "degrees", Kind: ArgExpr},
+		},
+		Help:           "rotates the robot by a multiple of 90 degrees, positive angles turn counterclockwise",
+		NeedsPlacement: true,
+		Parse: func(args []Value) error {
+			if n, ok := args[0].Expr.(expr.Num); ok && n%90 != 0 {
+				return fmt.Errorf("invalid degrees parameter detected(%d): %w", n, direction.ErrInvalidAngle)
+			}
+			return nil
+		},
+		Exec: func(t Table, env *Env, args []Value) error {
+			n, err := env.Eval(t, args[0].Expr)
+			if err != nil {
+				return err
+			}
+			_, err = t.TurnRobot(n)
+			return err
+		},
+	},
+	{
+		Name:           "REPORT",
+		Help:           "reports the robot position and facing",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			return t.Report()
+		},
+	},
+	{
+		Name: "GOTO",
+		Args: []Arg{
+			{Name: "x", Kind: ArgExpr},
+			{Name: "y", Kind: ArgExpr},
+			{Name: "facing", Kind: ArgDirection, Optional: true},
+		},
+		Help:           "moves the robot along the shortest path around blocked cells to the given position and optionally facing",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			x, err := env.Eval(t, args[0].Expr)
+			if err != nil {
+				return err
+			}
+			y, err := env.Eval(t, args[1].Expr)
+			if err != nil {
+				return err
+			}
+			goal := plan.Goal{Pos: point.Point{X: x, Y: y}}
+			if len(args) > 2 {
+				goal.Facing = &args[2].Direction
+			}
+			return goTo(t, goal)
+		},
+	},
+	{
+		Name: "COVER",
+		Args: []Arg{
+			{Name: "strategy", Kind: ArgKeyword, Choices: []string{cover.Boustrophedon.String(), cover.Spiral.String()}, Optional: true},
+		},
+		Help:           "moves the robot through every cell it can reach, row by row or in a spiral",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			strategy := cover.Boustrophedon
+			if len(args) > 0 {
+				strategy, _ = cover.ParseStrategy(args[0].Name)
+			}
+
+			pos, facing, err := t.Robot()
+			if err != nil {
+				return err
+			}
+			res, err := cover.Plan(grid{t}, table.Pose{Pos: pos, Facing: facing}, strategy)
+			if err != nil {
+				return err
+			}
+			return follow(t, res.Steps)
+		},
+	},
+	{
+		Name: "EXPLORE",
+		Args: []Arg{
+			{Name: "strategy", Kind: ArgKeyword, Choices: []string{explore.LeftHand.String(), explore.Frontier.String()}, Optional: true},
+		},
+		Help:           "moves the robot around the table sensing only the cells next to it, following the wall on its left or going to the closest unexplored cell",
+		NeedsPlacement: true,
+		Exec: func(t Table, env *Env, args []Value) error {
+			strategy := explore.LeftHand
+			if len(args) > 0 {
+				strategy, _ = explore.ParseStrategy(args[0].Name)
+			}
+
+			pos, facing, err := t.Robot()
+			if err != nil {
+				return err
+			}
+			res, err := explore.Explore(grid{t}, table.Pose{Pos: pos, Facing: facing}, strategy)
+			if err != nil {
+				return err
+			}
+			return follow(t, res.Steps)
+		},
+	},
+	{
+		Name: "HALT",
+		Help: "stops the run, commands following it are not executed",
+		Exec: func(t Table, env *Env, args []Value) error {
+			return ErrHalt
+		},
+	},
+}
+
+// goTo plans the path to the goal on the table and follows it
+func goTo(t Table, goal plan.Goal) error {
+	pos, facing, err := t.Robot()
+	if err != nil {
+		return err
+	}
+
+	steps, err := plan.Plan(grid{t}, table.Pose{Pos: pos, Facing: facing}, goal, plan.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return follow(t, steps)
+}
+
+// follow executes the planned steps one by one
+func follow(t Table, steps []plan.Step) error {
+	var err error
+	for _, step := range steps {
+		switch step {
+		case plan.Move:
+			_, err = t.MoveRobot()
+		case plan.Left:
+			_, err = t.RotateRobot(true)
+		case plan.Right:
+			_, err = t.RotateRobot(false)
+		case plan.UTurn:
+			_, err = t.TurnRobot(180)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grid exposes blocked cells of the table to the planner
+type grid struct {
+	Table
+}
+
+func (g grid) Blocked(pos point.Point) bool {
+	b, ok := g.Table.(Blocked)
+	return ok && b.Blocked(pos)
+}
+
+// moveBy moves the robot by the number of steps given in stepsArgs, sign is
+// applied to the number of steps so that moves can go backward
+func moveBy(t Table, env *Env, args []Value, sign int) error {
+	n, err := env.Eval(t, args[0].Expr)
+	if err != nil {
+		return err
+	}
+	_, err = t.MoveRobotBy(sign*n, len(args) > 1)
+	return err
+}
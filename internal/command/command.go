@@ -1,135 +1,121 @@
 package command
 
 import (
-	"bufio"
+	"context"
 	"errors"
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
 
 	"robot/internal/direction"
 	"robot/internal/point"
+	"robot/internal/table"
 )
 
 type Table interface {
 	PlaceRobot(pos point.Point, facing direction.Direction) error
 	RotateRobot(left bool) (*direction.Direction, error)
 	MoveRobot() (*point.Point, error)
+	MoveRobotBy(steps int, partial bool) (*point.Point, error)
+	TurnRobot(degrees int) (*direction.Direction, error)
 	Report() error
+	Size() (uint, uint)
+	Robot() (point.Point, direction.Direction, error)
+}
+
+// Blocked is implemented by tables with cells the robot can not enter, tables
+// that do not implement it have no blocked cells
+type Blocked interface {
+	Blocked(pos point.Point) bool
 }
 
 // Command that can be executed against robot table
-type Command func(t Table)
+type Command func(t Table, env *Env) error
 
-var (
-	leftCmd Command = func(t Table) {
-		t.RotateRobot(true)
-	}
+// RunOption is an option that can be passed to `Run`
+type RunOption func(*Env)
 
-	rightCmd Command = func(t Table) {
-		t.RotateRobot(false)
-	}
+// StepHook is called after every command scanned from a command file was
+// executed, err is the error returned by the command including refusals
+type StepHook func(pos Pos, t Table, err error)
 
-	moveCmd Command = func(t Table) {
-		t.MoveRobot()
+// WithStepHook provides an option to observe every executed command
+func WithStepHook(hook StepHook) RunOption {
+	return func(env *Env) {
+		env.hook = hook
 	}
+}
 
-	reportCmd Command = func(t Table) {
-		t.Report()
-	}
-)
-
-// ScanCommandList parses commands from the text file
-func ScanCommandList(fileName string) ([]Command, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed opening file: %s", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-
-	cmdList := []Command{}
-	for scanner.Scan() {
-		var cmd Command
-		if err := cmd.Unmarshal(scanner.Text()); err != nil {
-			return nil, err
-		}
-		cmdList = append(cmdList, cmd)
+// WithMaxSteps provides an option to stop the run once the number of executed
+// commands reaches the budget, commands of procedures and loops are counted
+// as well as iterations of loops with an empty body. Zero leaves the run
+// unlimited
+func WithMaxSteps(n int) RunOption {
+	return func(env *Env) {
+		env.maxSteps = n
 	}
+}
 
-	return cmdList, nil
+// Run executes commands against the table in order, commands refused by the
+// table are ignored while any other failure stops the run. HALT stops the run
+// without an error
+func Run(t Table, cmds []Command, opts ...RunOption) error {
+	return RunContext(context.Background(), t, cmds, opts...)
 }
 
-// Unmarshal deserialize individual command
-func (c *Command) Unmarshal(s interface{}) error {
-	src, ok := s.(string)
-	if !ok {
-		return errors.New("non string source data types not supported")
+// RunContext is `Run` that is stopped once the context is done, the context is
+// checked before every command. A run stopped by the context or by the step
+// budget returns a `PartialError`
+func RunContext(ctx context.Context, t Table, cmds []Command, opts ...RunOption) error {
+	env := NewEnvContext(ctx, opts...)
+
+	err := env.exec(t, cmds)
+	ctxErr := ctx.Err()
+	var cause error
+	switch {
+	case err == nil, errors.Is(err, ErrHalt):
+		return nil
+	case errors.Is(err, ErrMaxSteps):
+		cause = ErrMaxSteps
+	case ctxErr != nil && errors.Is(err, ctxErr):
+		cause = ctxErr
+	default:
+		return err
 	}
 
-	txtCmd := strings.Fields(strings.TrimSpace(strings.ToUpper(src)))
-	if len(txtCmd) == 0 {
-		return fmt.Errorf("empty command detected: '%s'", src)
+	perr := &PartialError{Steps: env.steps, Pos: env.pos, Err: cause}
+	if pos, facing, err := t.Robot(); err == nil {
+		perr.Robot = &table.Pose{Pos: pos, Facing: facing}
 	}
+	return perr
+}
 
-	switch txtCmd[0] {
-	case "PLACE":
-		var cmdParams []string
-		if len(txtCmd) > 1 {
-			cmdParams = strings.Split(txtCmd[1], ",")
+// at wraps the command so that its failures name the position it was
+// scanned from
+func (c Command) at(pos Pos) Command {
+	return func(t Table, env *Env) error {
+		env.pos = pos
+		err := c(t, env)
+		if env.hook != nil {
+			env.hook(pos, t, err)
 		}
-		if len(cmdParams) < 3 {
-			return fmt.Errorf("PLACE command requires 3 parameters, but %d were detected: '%s'", len(cmdParams), src)
-		}
-		cmd, err := placeCmd(cmdParams[0], cmdParams[1], cmdParams[2])
 		if err != nil {
-			return err
+			return &ExecError{Pos: pos, Err: err}
 		}
-		*c = cmd
-
-	case "LEFT":
-		*c = leftCmd
-	case "RIGHT":
-		*c = rightCmd
-	case "MOVE":
-		*c = moveCmd
-	case "REPORT":
-		*c = reportCmd
-	default:
-		return fmt.Errorf("invalid command detected: '%s'", src)
+		return nil
 	}
-	return nil
 }
 
-// placecmd deserialize place command
-func placeCmd(x, y, drctn string) (Command, error) {
-	posX, err := strconv.Atoi(x)
-	if err != nil {
-		return nil, fmt.Errorf("x pos parameters not a number(%s): %s", x, err.Error())
-	}
-	posY, err := strconv.Atoi(y)
-	if err != nil {
-		return nil, fmt.Errorf("y pos parameters not a number(%s): %s", y, err.Error())
+// Unmarshal deserialize individual command using the default registry,
+// expressions may only reference built-in values as there are no variables
+// assigned before it
+func (c *Command) Unmarshal(s interface{}) error {
+	src, ok := s.(string)
+	if !ok {
+		return errors.New("non string source data types not supported")
 	}
 
-	var d direction.Direction
-	switch drctn {
-	case "EAST":
-		d = direction.East
-	case "NORTH":
-		d = direction.North
-	case "WEST":
-		d = direction.West
-	case "SOUTH":
-		d = direction.South
-	default:
-		return nil, fmt.Errorf("invalid direction parameter detected: '%s'", drctn)
+	cmd, err := DefaultRegistry.Parse(src)
+	if err != nil {
+		return err
 	}
-
-	return func(t Table) {
-		t.PlaceRobot(point.Point{X: posX, Y: posY}, d)
-	}, nil
+	*c = cmd
+	return nil
 }
@@ -2,11 +2,16 @@ package command_test
 
 import (
 	"bytes"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"robot/internal/command"
+	"robot/internal/expr"
+	"robot/internal/plan"
+	"robot/internal/point"
 	"robot/internal/table"
 )
 
@@ -56,6 +61,18 @@ func TestScanCommandList(t *testing.T) {
 			},
 			shouldErr: false,
 		},
+		{
+			name:        "should successfully scan commands with included setup",
+			tbl:         &tableMock{},
+			commandFile: "./fixtures/include/scenarios/walk.txt",
+			expectedFnCnt: map[string]int{
+				"PlaceRobot":  1,
+				"MoveRobot":   3,
+				"RotateRobot": 1,
+				"Report":      1,
+			},
+			shouldErr: false,
+		},
 		{
 			name:          "should fail to scan invalid commands",
 			tbl:           &tableMock{},
@@ -76,9 +93,8 @@ func TestScanCommandList(t *testing.T) {
 				return
 			}
 
-			for _, cmd := range cmds {
-				cmd(tt.tbl)
-			}
+			err = command.Run(tt.tbl, cmds)
+			require.NoError(t, err)
 			require.EqualValues(t, tt.expectedFnCnt, tt.tbl.fnCnt)
 		})
 	}
@@ -88,34 +104,386 @@ func TestIntegration(t *testing.T) {
 	tests := [...]struct {
 		name           string
 		commandFile    string
+		sizeX          uint
+		sizeY          uint
 		expectedReport string
 	}{
 		{
 			name:           "should successfully scan commands to draw letter y",
 			commandFile:    "./fixtures/y.txt",
+			sizeX:          5,
+			sizeY:          5,
 			expectedReport: "Robot position: (2, 0) facing: SOUTH\n",
 		},
 		{
 			name:           "should successfully scan commands to draw letter u",
 			commandFile:    "./fixtures/u.txt",
+			sizeX:          5,
+			sizeY:          5,
 			expectedReport: "Robot position: (2, 4) facing: NORTH\n",
 		},
 		{
 			name:           "should successfully scan commands to draw letter m",
 			commandFile:    "./fixtures/m.txt",
+			sizeX:          5,
+			sizeY:          5,
 			expectedReport: "Robot position: (3, 0) facing: SOUTH\n",
 		},
+		{
+			name:           "should successfully scan commands with included setup",
+			commandFile:    "./fixtures/include/scenarios/walk.txt",
+			sizeX:          5,
+			sizeY:          5,
+			expectedReport: "Robot position: (2, 3) facing: EAST\n",
+		},
+		{
+			name:           "should successfully run commands moving by several steps",
+			commandFile:    "./fixtures/steps.txt",
+			sizeX:          5,
+			sizeY:          5,
+			expectedReport: "Robot position: (0, 3) facing: NORTH\nRobot position: (0, 4) facing: SOUTH\nRobot position: (4, 4) facing: EAST\n",
+		},
+		{
+			name:           "should successfully run commands using variables on 5x5 table",
+			commandFile:    "./fixtures/vars.txt",
+			sizeX:          5,
+			sizeY:          5,
+			expectedReport: "Robot position: (4, 2) facing: WEST\nRobot position: (0, 0) facing: NORTH\n",
+		},
+		{
+			name:           "should successfully run commands using variables on 9x3 table",
+			commandFile:    "./fixtures/vars.txt",
+			sizeX:          9,
+			sizeY:          3,
+			expectedReport: "Robot position: (8, 1) facing: WEST\nRobot position: (4, 0) facing: NORTH\n",
+		},
+		{
+			name:           "should successfully stop the run on halt",
+			commandFile:    "./fixtures/halt.txt",
+			sizeX:          5,
+			sizeY:          5,
+			expectedReport: "Robot position: (1, 2) facing: NORTH\n",
+		},
+		{
+			name:           "should successfully run nested loops",
+			commandFile:    "./fixtures/repeat.txt",
+			sizeX:          5,
+			sizeY:          5,
+			expectedReport: "Robot position: (4, 4) facing: WEST\n",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			reportBuf := bytes.NewBufferString("")
-			tbl := table.New(5, 5, table.WithReportOutput(reportBuf))
+			tbl := table.New(tt.sizeX, tt.sizeY, table.WithReportOutput(reportBuf))
 			cmds, _ := command.ScanCommandList(tt.commandFile)
-			for _, cmd := range cmds {
-				cmd(tbl)
-			}
+			err := command.Run(tbl, cmds)
+			require.NoError(t, err)
 			require.EqualValues(t, tt.expectedReport, reportBuf.String())
 		})
 	}
 }
+
+func TestScanCommandListErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		commandFile string
+		expectedErr error
+		expectedMsg string
+	}{
+		{
+			name:        "should fail to scan commands with include cycle",
+			commandFile: "./fixtures/include/a.txt",
+			expectedErr: command.ErrIncludeCycle,
+			expectedMsg: "./fixtures/include/a.txt:1: fixtures/include/b.txt:2: include cycle detected: 'fixtures/include/a.txt' is already being scanned",
+		},
+		{
+			name:        "should name included file when it contains invalid command",
+			commandFile: "./fixtures/include/outer.txt",
+			expectedMsg: "./fixtures/include/outer.txt:2: fixtures/include/broken.txt:2: invalid command detected: 'JUMP'",
+		},
+		{
+			name:        "should fail to scan commands using variable before it is set",
+			commandFile: "./fixtures/undefined.txt",
+			expectedErr: expr.ErrUndefined,
+			expectedMsg: "./fixtures/undefined.txt:2: x parameter is not a valid expression(X): undefined identifier: X",
+		},
+		{
+			name:        "should fail to run commands dividing by zero",
+			commandFile: "./fixtures/zero.txt",
+			expectedErr: expr.ErrDivisionByZero,
+			expectedMsg: "./fixtures/zero.txt:2: division by zero",
+		},
+		{
+			name:        "should fail to scan include without quoted file name",
+			commandFile: "./fixtures/include/unquoted.txt",
+			expectedMsg: "./fixtures/include/unquoted.txt:1: INCLUDE command requires a quoted file name: 'INCLUDE missing.txt'",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := command.ScanCommandList(tt.commandFile)
+			if err == nil {
+				err = command.Run(table.New(5, 5), cmds)
+			}
+			require.Error(t, err)
+			require.Equal(t, tt.expectedMsg, err.Error())
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGoto(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		src            string
+		expectedReport string
+		expectedErr    error
+	}{
+		{
+			name:           "should go to position around blocked cells",
+			src:            "PLACE 0,0,EAST\nGOTO 0,3\nREPORT\n",
+			expectedReport: "Robot position: (0, 3) facing: WEST\n",
+		},
+		{
+			name:           "should go to position and facing",
+			src:            "PLACE 0,0,EAST\nGOTO WIDTH-1,HEIGHT-1,SOUTH\nREPORT\n",
+			expectedReport: "Robot position: (3, 3) facing: SOUTH\n",
+		},
+		{
+			name:           "should ignore goto outside of the table or to blocked cell",
+			src:            "GOTO 1,1\nPLACE 0,0,EAST\nGOTO 4,0\nGOTO 2,0\nREPORT\n",
+			expectedReport: "Robot position: (0, 0) facing: EAST\n",
+		},
+		{
+			name:           "should fail to go to walled off position",
+			src:            "PLACE 0,0,EAST\nGOTO 3,0\nREPORT\n",
+			expectedReport: "",
+			expectedErr:    plan.ErrUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := command.ScanCommandList("goto.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+				return []byte(tt.src), nil
+			}))
+			require.NoError(t, err)
+
+			// ....
+			// ##..
+			// ...#
+			// ..#.
+			reportBuf := bytes.NewBufferString("")
+			tbl := table.New(4, 4, table.WithReportOutput(reportBuf), table.WithBlocked(
+				point.Point{X: 0, Y: 2}, point.Point{X: 1, Y: 2}, point.Point{X: 3, Y: 1}, point.Point{X: 2, Y: 0},
+			))
+			err = command.Run(tbl, cmds)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedReport, reportBuf.String())
+		})
+	}
+}
+
+func TestCoverExplore(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		src            string
+		expectedReport string
+	}{
+		{
+			name:           "should sweep reachable cells",
+			src:            "PLACE 0,0,EAST\nCOVER\nREPORT\n",
+			expectedReport: "Robot position: (0, 3) facing: WEST\n",
+		},
+		{
+			name:           "should cover reachable cells in a spiral",
+			src:            "PLACE 0,3,EAST\nCOVER SPIRAL\nREPORT\n",
+			expectedReport: "Robot position: (1, 0) facing: EAST\n",
+		},
+		{
+			name:           "should explore following walls",
+			src:            "PLACE 0,0,NORTH\nEXPLORE\nREPORT\n",
+			expectedReport: "Robot position: (1, 1) facing: EAST\n",
+		},
+		{
+			name:           "should explore frontiers",
+			src:            "PLACE 0,0,NORTH\nEXPLORE FRONTIER\nREPORT\n",
+			expectedReport: "Robot position: (0, 3) facing: WEST\n",
+		},
+		{
+			name:           "should ignore cover when not placed",
+			src:            "COVER\nREPORT\n",
+			expectedReport: "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := command.ScanCommandList("walk.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+				return []byte(tt.src), nil
+			}))
+			require.NoError(t, err)
+
+			// ....
+			// ##..
+			// ...#
+			// ..#.
+			reportBuf := bytes.NewBufferString("")
+			tbl := table.New(4, 4, table.WithReportOutput(reportBuf), table.WithBlocked(
+				point.Point{X: 0, Y: 2}, point.Point{X: 1, Y: 2}, point.Point{X: 3, Y: 1}, point.Point{X: 2, Y: 0},
+			))
+			require.NoError(t, command.Run(tbl, cmds))
+			require.Equal(t, tt.expectedReport, reportBuf.String())
+		})
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	t.Parallel()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := [...]struct {
+		name           string
+		ctx            context.Context
+		src            string
+		maxSteps       int
+		expectedReport string
+		expectedErr    string
+	}{
+		{
+			name:           "should run within the step budget",
+			ctx:            context.Background(),
+			src:            "PLACE 0,0,NORTH\nREPEAT 2\nMOVE\nEND\nREPORT\n",
+			maxSteps:       5,
+			expectedReport: "Robot position: (0, 2) facing: NORTH\n",
+		},
+		{
+			name:        "should stop inside loop when the step budget is exhausted",
+			ctx:         context.Background(),
+			src:         "PLACE 0,0,NORTH\nREPEAT 10\nMOVE\nEND\nREPORT\n",
+			maxSteps:    4,
+			expectedErr: "stopped at loop.txt:3 after 4 steps with robot at 0,2,NORTH: step budget exhausted",
+		},
+		{
+			name:        "should stop loop with empty body when the step budget is exhausted",
+			ctx:         context.Background(),
+			src:         "PLACE 0,0,NORTH\nREPEAT 1000000000000\nEND\nREPORT\n",
+			maxSteps:    100,
+			expectedErr: "stopped at loop.txt:2 after 100 steps with robot at 0,0,NORTH: step budget exhausted",
+		},
+		{
+			name:        "should not start when the context is done",
+			ctx:         cancelled,
+			src:         "PLACE 0,0,NORTH\nREPORT\n",
+			expectedErr: "stopped after 0 steps with robot not placed: context canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := command.ScanCommandList("loop.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+				return []byte(tt.src), nil
+			}))
+			require.NoError(t, err)
+
+			reportBuf := bytes.NewBufferString("")
+			tbl := table.New(5, 5, table.WithReportOutput(reportBuf))
+			err = command.RunContext(tt.ctx, tbl, cmds, command.WithMaxSteps(tt.maxSteps))
+			if tt.expectedErr != "" {
+				var perr *command.PartialError
+				require.ErrorAs(t, err, &perr)
+				require.EqualError(t, perr, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedReport, reportBuf.String())
+		})
+	}
+}
+
+func TestRunContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	cmds, err := command.ScanCommandList("spin.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+		return []byte("PLACE 1,1,EAST\nREPEAT 1000000000\nLEFT\nEND\n"), nil
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = command.RunContext(ctx, table.New(5, 5), cmds)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var perr *command.PartialError
+	require.ErrorAs(t, err, &perr)
+	require.Greater(t, perr.Steps, 2)
+	require.Equal(t, command.Pos{File: "spin.txt", Line: 3}, perr.Pos)
+	require.NotNil(t, perr.Robot)
+	require.Equal(t, point.Point{X: 1, Y: 1}, perr.Robot.Pos)
+}
+
+func TestRunContextTimeout(t *testing.T) {
+	t.Parallel()
+
+	cmds, err := command.ScanCommandList("loop.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+		return []byte("PLACE 0,0,NORTH\nREPEAT 1000000000000\nEND\n"), nil
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = command.RunContext(ctx, table.New(5, 5), cmds)
+	var perr *command.PartialError
+	require.ErrorAs(t, err, &perr)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestEnvExec(t *testing.T) {
+	t.Parallel()
+
+	cmds, err := command.ScanCommandList("count.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+		return []byte("PLACE 0,0,NORTH\nSET n = 3\nREPEAT n\nLEFT\nEND\n"), nil
+	}))
+	require.NoError(t, err)
+
+	tbl := table.New(5, 5)
+	env := command.NewEnvContext(context.Background(), command.WithMaxSteps(4))
+	require.NoError(t, env.Exec(tbl, cmds[:2]...))
+	// the budget applies to each call and variables are kept between calls
+	require.NoError(t, env.Exec(tbl, cmds[2:]...))
+	require.ErrorIs(t, env.Exec(tbl, cmds[2], cmds[2]), command.ErrMaxSteps)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	env = command.NewEnvContext(ctx)
+	require.ErrorIs(t, env.Exec(tbl, cmds...), context.Canceled)
+}
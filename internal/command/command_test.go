@@ -52,6 +52,83 @@ func TestUnmarshal(t *testing.T) {
 			expectedFnCnt: map[string]int{"MoveRobot": 1},
 			shouldErr:     false,
 		},
+		{
+			name: "should unmarshal move command with number of steps",
+			tbl: &tableMock{
+				moveRobotByFn: func(steps int, partial bool) (*point.Point, error) {
+					require.Equal(t, 4, steps)
+					require.Equal(t, false, partial)
+					return nil, nil
+				},
+			},
+			command:       "MOVE 2*2",
+			expectedFnCnt: map[string]int{"MoveRobotBy": 1},
+			shouldErr:     false,
+		},
+		{
+			name: "should unmarshal partial move command",
+			tbl: &tableMock{
+				moveRobotByFn: func(steps int, partial bool) (*point.Point, error) {
+					require.Equal(t, 3, steps)
+					require.Equal(t, true, partial)
+					return nil, nil
+				},
+			},
+			command:       "MOVE 3, partial",
+			expectedFnCnt: map[string]int{"MoveRobotBy": 1},
+			shouldErr:     false,
+		},
+		{
+			name:          "should fail to unmarshal move command with unknown mode",
+			tbl:           &tableMock{},
+			command:       "MOVE 3,SOMETIMES",
+			expectedFnCnt: map[string]int{},
+			shouldErr:     true,
+		},
+		{
+			name: "should unmarshal back command",
+			tbl: &tableMock{
+				moveRobotByFn: func(steps int, partial bool) (*point.Point, error) {
+					require.Equal(t, -1, steps)
+					require.Equal(t, false, partial)
+					return nil, nil
+				},
+			},
+			command:       "BACK",
+			expectedFnCnt: map[string]int{"MoveRobotBy": 1},
+			shouldErr:     false,
+		},
+		{
+			name: "should unmarshal uturn command",
+			tbl: &tableMock{
+				turnRobotFn: func(degrees int) (*direction.Direction, error) {
+					require.Equal(t, 180, degrees)
+					return nil, nil
+				},
+			},
+			command:       "UTURN",
+			expectedFnCnt: map[string]int{"TurnRobot": 1},
+			shouldErr:     false,
+		},
+		{
+			name: "should unmarshal turn command",
+			tbl: &tableMock{
+				turnRobotFn: func(degrees int) (*direction.Direction, error) {
+					require.Equal(t, -90, degrees)
+					return nil, nil
+				},
+			},
+			command:       "TURN -90",
+			expectedFnCnt: map[string]int{"TurnRobot": 1},
+			shouldErr:     false,
+		},
+		{
+			name:          "should fail to unmarshal turn command with invalid angle",
+			tbl:           &tableMock{},
+			command:       "TURN 45",
+			expectedFnCnt: map[string]int{},
+			shouldErr:     true,
+		},
 		{
 			name: "should unmarshal left command",
 			tbl: &tableMock{
@@ -83,6 +160,66 @@ func TestUnmarshal(t *testing.T) {
 			expectedFnCnt: map[string]int{"Report": 1},
 			shouldErr:     false,
 		},
+		{
+			name: "should unmarshal place command with expressions",
+			tbl: &tableMock{
+				sizeFn: func() (uint, uint) {
+					return 7, 3
+				},
+				placeRobotFn: func(pos point.Point, facing direction.Direction) error {
+					require.Equal(t, point.Point{X: 6, Y: 1}, pos)
+					require.Equal(t, direction.East, facing)
+					return nil
+				},
+			},
+			command:       "PLACE width-1, height/2, EAST",
+			expectedFnCnt: map[string]int{"PlaceRobot": 1, "Size": 2},
+			shouldErr:     false,
+		},
+		{
+			name: "should unmarshal place command relative to robot position",
+			tbl: &tableMock{
+				robotFn: func() (point.Point, direction.Direction, error) {
+					return point.Point{X: 2, Y: 3}, direction.South, nil
+				},
+				placeRobotFn: func(pos point.Point, facing direction.Direction) error {
+					require.Equal(t, point.Point{X: 0, Y: 9}, pos)
+					require.Equal(t, direction.West, facing)
+					return nil
+				},
+			},
+			command:       "PLACE POSX-2,POSY*(1+2),WEST",
+			expectedFnCnt: map[string]int{"PlaceRobot": 1, "Robot": 2},
+			shouldErr:     false,
+		},
+		{
+			name:          "should fail to unmarshal place command with undefined variable",
+			tbl:           &tableMock{},
+			command:       "PLACE w,1,NORTH",
+			expectedFnCnt: map[string]int{},
+			shouldErr:     true,
+		},
+		{
+			name:          "should unmarshal set command",
+			tbl:           &tableMock{},
+			command:       "SET w = WIDTH - 1",
+			expectedFnCnt: map[string]int{"Size": 1},
+			shouldErr:     false,
+		},
+		{
+			name:          "should fail to unmarshal set command assigning built-in value",
+			tbl:           &tableMock{},
+			command:       "SET width = 3",
+			expectedFnCnt: map[string]int{},
+			shouldErr:     true,
+		},
+		{
+			name:          "should fail to unmarshal set command without assignment",
+			tbl:           &tableMock{},
+			command:       "SET w 3",
+			expectedFnCnt: map[string]int{},
+			shouldErr:     true,
+		},
 		{
 			name: "should fail to unmarshal unknown command",
 			tbl: &tableMock{
@@ -109,8 +246,33 @@ func TestUnmarshal(t *testing.T) {
 				return
 			}
 
-			cmd(tt.tbl)
+			err = cmd(tt.tbl, command.NewEnv())
+			require.NoError(t, err)
 			require.Equal(t, tt.expectedFnCnt, tt.tbl.fnCnt)
 		})
 	}
 }
+
+func TestStripComment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{name: "should keep lines without comments", line: "MOVE 2", expected: "MOVE 2"},
+		{name: "should strip trailing comments", line: "MOVE 2 # ahead", expected: "MOVE 2 "},
+		{name: "should strip whole line comments", line: "# ahead", expected: ""},
+		{name: "should keep '#' of quoted file names", line: `INCLUDE "a#b.txt" # c`, expected: `INCLUDE "a#b.txt" `},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, command.StripComment(tt.line))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"robot/internal/expr"
+	"robot/internal/table"
+)
+
+// builtinValues are read only values derived from the table and the robot that
+// can be referenced in expressions
+var builtinValues = map[string]func(t Table) (int, error){
+	"WIDTH": func(t Table) (int, error) {
+		sizeX, _ := t.Size()
+		return int(sizeX), nil
+	},
+	"HEIGHT": func(t Table) (int, error) {
+		_, sizeY := t.Size()
+		return int(sizeY), nil
+	},
+	"POSX": func(t Table) (int, error) {
+		pos, _, err := t.Robot()
+		return pos.X, err
+	},
+	"POSY": func(t Table) (int, error) {
+		pos, _, err := t.Robot()
+		return pos.Y, err
+	},
+}
+
+// Builtins returns sorted names of the built-in values
+func Builtins() []string {
+	names := make([]string, 0, len(builtinValues))
+	for name := range builtinValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Env holds the state shared by commands during a single run
+type Env struct {
+	vars map[string]int
+	hook StepHook
+	ctx  context.Context
+	// maxSteps limits the number of executed commands when positive
+	maxSteps int
+	steps    int
+	// pos is the position of the latest command scanned from a command file
+	pos Pos
+}
+
+func NewEnv() *Env {
+	return NewEnvContext(context.Background())
+}
+
+// NewEnvContext returns an env for commands executed one at a time by `Exec`,
+// the context and the options apply to every call
+func NewEnvContext(ctx context.Context, opts ...RunOption) *Env {
+	env := &Env{
+		vars: map[string]int{},
+		ctx:  ctx,
+	}
+	for _, opt := range opts {
+		opt(env)
+	}
+	return env
+}
+
+// Exec executes commands the way `RunContext` does but keeps the variables
+// for later calls, the step budget applies to each call. Failures are returned
+// as they are, e.g. ErrHalt, ErrMaxSteps or the error of the context
+func (e *Env) Exec(t Table, cmds ...Command) error {
+	e.steps = 0
+	return e.exec(t, cmds)
+}
+
+// exec executes commands in order, commands refused by the table are ignored
+// while any other failure stops the execution. The context and the step
+// budget are checked before every command
+func (e *Env) exec(t Table, cmds []Command) error {
+	for _, cmd := range cmds {
+		if err := e.step(); err != nil {
+			return err
+		}
+		if err := cmd(t, e); err != nil && !table.IsRefusal(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// step accounts a single step of the run, it fails once the context is done or
+// the step budget is exhausted
+func (e *Env) step() error {
+	if err := e.ctx.Err(); err != nil {
+		return err
+	}
+	if e.maxSteps > 0 && e.steps >= e.maxSteps {
+		return ErrMaxSteps
+	}
+	e.steps++
+	return nil
+}
+
+// Scope keeps variables between commands that are parsed and run one at a
+// time, e.g. lines of an interactive session. Commands parsed in the scope may
+// reference variables assigned by the ones parsed before them. A scope is not
+// safe for concurrent use
+type Scope struct {
+	registry *Registry
+	declared map[string]bool
+	vars     map[string]int
+}
+
+// NewScope returns an empty scope parsing commands of the registry
+func (r *Registry) NewScope() *Scope {
+	return &Scope{
+		registry: r,
+		declared: map[string]bool{},
+		vars:     map[string]int{},
+	}
+}
+
+// Parse deserialize individual command, expressions may reference variables
+// assigned by commands parsed in the scope before it
+func (s *Scope) Parse(src string) (Command, error) {
+	return s.registry.parse(src, s.declared)
+}
+
+// WithScope provides an option to run commands with the variables of the
+// scope, variables assigned during the run are kept in the scope
+func WithScope(s *Scope) RunOption {
+	return func(env *Env) {
+		env.vars = s.vars
+	}
+}
+
+// Set assigns the value to the variable
+func (e *Env) Set(name string, value int) {
+	e.vars[name] = value
+}
+
+// Eval evaluates the expression, identifiers are resolved to variables set
+// during the run or to built-in values of the table
+func (e *Env) Eval(t Table, x expr.Expr) (int, error) {
+	return x.Eval(func(name string) (int, error) {
+		if builtin, ok := builtinValues[name]; ok {
+			v, err := builtin(t)
+			if err != nil {
+				return 0, fmt.Errorf("failed evaluating %s: %w", name, err)
+			}
+			return v, nil
+		}
+
+		v, ok := e.vars[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", expr.ErrUndefined, name)
+		}
+		return v, nil
+	})
+}
+
+// parseExpr parses the expression and checks that all referenced identifiers
+// are either built-in or declared
+func parseExpr(src string, declared map[string]bool) (expr.Expr, error) {
+	x, err := expr.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range expr.Idents(x) {
+		if _, ok := builtinValues[name]; !ok && !declared[name] {
+			return nil, fmt.Errorf("%w: %s", expr.ErrUndefined, name)
+		}
+	}
+	return x, nil
+}
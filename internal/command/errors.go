@@ -0,0 +1,116 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"robot/internal/table"
+)
+
+var (
+	ErrIncludeCycle error = errors.New("include cycle detected")
+	// ErrHalt is returned by HALT to stop the run without failing it
+	ErrHalt error = errors.New("halted")
+	// ErrMaxSteps is returned once a run executed as many commands as allowed
+	ErrMaxSteps error = errors.New("step budget exhausted")
+)
+
+// Pos identifies a line in a command file
+type Pos struct {
+	File string
+	Line int
+}
+
+// String returns a string representation of Pos in the file:line form
+func (p Pos) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// ScanError is returned when a command file can not be scanned, it names the
+// file and the line at which scanning failed
+type ScanError struct {
+	Pos Pos
+	Err error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// ExecError is returned when a command fails during the run, it names the
+// file and the line the command was scanned from
+type ExecError struct {
+	Pos Pos
+	Err error
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned when a run was stopped before it finished by its
+// context or its step budget, it tells how far the run got
+type PartialError struct {
+	// Steps is the number of commands executed before the run was stopped
+	Steps int
+	// Pos is the position of the latest command started, it is zero when no
+	// command scanned from a command file was started
+	Pos Pos
+	// Robot is the pose of the robot when the run was stopped, nil when the
+	// robot was not placed
+	Robot *table.Pose
+	Err   error
+}
+
+func (e *PartialError) Error() string {
+	robot := "not placed"
+	if e.Robot != nil {
+		robot = "at " + e.Robot.String()
+	}
+	if e.Pos == (Pos{}) {
+		return fmt.Sprintf("stopped after %d steps with robot %s: %s", e.Steps, robot, e.Err)
+	}
+	return fmt.Sprintf("stopped at %s after %d steps with robot %s: %s", e.Pos, e.Steps, robot, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// ScanErrors is a list of scan errors reported at once
+type ScanErrors []*ScanError
+
+func (e ScanErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the first error of the list, the module targets a Go version
+// predating errors wrapping several errors so the remaining ones are only
+// reachable by ranging over the list
+func (e ScanErrors) Unwrap() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e[0]
+}
+
+// Err returns nil when the list is empty and the list otherwise
+func (e ScanErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"robot/internal/expr"
+)
+
+// indent is written once per nesting level of a block
+const indent = "  "
+
+// Format returns the canonical source of the file using the default registry
+func Format(f *File) []byte {
+	return DefaultRegistry.Format(f)
+}
+
+// Format returns the canonical source of the file: upper case keywords,
+// parameters separated by a comma without spaces, blocks indented and runs of
+// blank lines collapsed into one. Comments are kept at the lines they
+// preceded, parameters of commands that are not registered are kept as is
+func (r *Registry) Format(f *File) []byte {
+	p := &printer{
+		registry: r,
+		comments: f.Comments,
+	}
+	for _, n := range f.Nodes {
+		p.node(n, 0)
+	}
+	p.flush(0, 0)
+
+	return p.buf.Bytes()
+}
+
+// printer writes statements and the comments in between them
+type printer struct {
+	registry *Registry
+	buf      bytes.Buffer
+	comments []*Comment
+	// line is the source line of the last written statement or comment
+	line int
+	// open is set while nothing was written after the start of a block
+	open bool
+}
+
+// node writes the statement and its block at the given nesting level
+func (p *printer) node(n *Node, depth int) {
+	p.flush(n.Pos.Line, depth)
+	p.blank(n.Pos.Line)
+	p.write(depth, p.statement(n), n.Comment, n.Pos.Line)
+
+	if n.Name != "DEF" && n.Name != "REPEAT" {
+		return
+	}
+	p.open = true
+	for _, child := range n.Body {
+		p.node(child, depth+1)
+	}
+	p.flush(n.End.Line, depth+1)
+	// there is no blank line before END
+	p.write(depth, "END", n.EndComment, n.End.Line)
+}
+
+// flush writes comments preceding the line, zero line writes all that are
+// left
+func (p *printer) flush(line int, depth int) {
+	for len(p.comments) > 0 && (line == 0 || p.comments[0].Pos.Line < line) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		p.blank(c.Pos.Line)
+		p.write(depth, "", c.Text, c.Pos.Line)
+	}
+}
+
+// blank writes a single blank line when the source had one or more blank
+// lines between the last written line and the given one, there is no blank
+// line right after the start of a block
+func (p *printer) blank(line int) {
+	if p.line > 0 && line > p.line+1 && !p.open {
+		p.buf.WriteString("\n")
+	}
+}
+
+// write writes indented statement followed by the comment
+func (p *printer) write(depth int, stmt string, comment string, line int) {
+	p.buf.WriteString(strings.Repeat(indent, depth))
+	p.buf.WriteString(stmt)
+	if comment != "" || stmt == "" {
+		if stmt != "" {
+			p.buf.WriteString(" ")
+		}
+		p.buf.WriteString(formatComment(comment))
+	}
+	p.buf.WriteString("\n")
+	p.open = false
+	if line > 0 {
+		p.line = line
+	}
+}
+
+// statement returns the canonical form of the statement without its block
+func (p *printer) statement(n *Node) string {
+	switch n.Name {
+	case "INCLUDE":
+		return n.Name + " " + strconv.Quote(n.Params[0])
+	case "DEF", "CALL":
+		return n.Name + " " + strings.Join(n.Params, ",")
+	case "REPEAT":
+		if len(n.Params) == 1 {
+			return n.Name + " " + formatParam(Arg{Kind: ArgExpr}, n.Params[0])
+		}
+	}
+
+	params := make([]string, len(n.Params))
+	copy(params, n.Params)
+	if spec, ok := p.registry.Lookup(n.Name); ok {
+		for i := range params {
+			if i < len(spec.Args) {
+				params[i] = formatParam(spec.Args[i], params[i])
+			}
+		}
+	}
+
+	if len(params) == 0 {
+		return n.Name
+	}
+	return n.Name + " " + strings.Join(params, ",")
+}
+
+// formatParam returns the canonical form of the parameter, parameters that
+// are not valid are kept as is
+func formatParam(arg Arg, param string) string {
+	switch arg.Kind {
+	case ArgExpr:
+		if x, err := expr.Parse(param); err == nil {
+			return x.String()
+		}
+	case ArgAssignment:
+		eq := strings.Index(param, "=")
+		if eq < 0 {
+			return param
+		}
+		name := strings.TrimSpace(param[:eq])
+		if x, err := expr.Parse(param[eq+1:]); err == nil {
+			return name + " = " + x.String()
+		}
+	}
+	return param
+}
+
+// formatComment returns the comment with a single space after '#'
+func formatComment(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "#"
+	}
+	return "# " + text
+}
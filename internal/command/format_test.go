@@ -0,0 +1,72 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "should upper case keywords and remove spaces between parameters",
+			src:      "place 0, 4 ,south\n  move\nleft\r\nreport",
+			expected: "PLACE 0,4,SOUTH\nMOVE\nLEFT\nREPORT\n",
+		},
+		{
+			name:     "should format expressions and assignments",
+			src:      "set  n=( width - 1 )*2\nmove n / 2 , partial\nturn -90",
+			expected: "SET N = (WIDTH-1)*2\nMOVE N/2,PARTIAL\nTURN -90\n",
+		},
+		{
+			name:     "should indent blocks and collapse blank lines",
+			src:      "\n\ndef side\n\n move 2\n\n\n left\n\nend\n\n\n\ncall side\n\n",
+			expected: "DEF SIDE\n  MOVE 2\n\n  LEFT\nEND\n\nCALL SIDE\n",
+		},
+		{
+			name:     "should keep comments",
+			src:      "#setup\ninclude \"setup.txt\"   #  place first\n\n  # walk\nDEF walk # proc\n# inside\nmove\n  #last\nend#done\n#eof",
+			expected: "# setup\nINCLUDE \"setup.txt\" # place first\n\n# walk\nDEF WALK # proc\n  # inside\n  MOVE\n  # last\nEND # done\n# eof\n",
+		},
+		{
+			name:     "should indent nested loops",
+			src:      "def p\nrepeat width - 1\n\nrepeat 2\nmove\nend\nend\nend",
+			expected: "DEF P\n  REPEAT WIDTH-1\n    REPEAT 2\n      MOVE\n    END\n  END\nEND\n",
+		},
+		{
+			name:     "should keep parameters of unknown commands",
+			src:      "jump 1 ,  2",
+			expected: "JUMP 1,2\n",
+		},
+		{
+			name:     "should keep the hash of quoted file names",
+			src:      "INCLUDE \"#1.txt\" # first",
+			expected: "INCLUDE \"#1.txt\" # first\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := command.Parse("commands.txt", []byte(tt.src))
+			require.NoError(t, err)
+
+			formatted := command.Format(f)
+			require.Equal(t, tt.expected, string(formatted))
+
+			f, err = command.Parse("commands.txt", formatted)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(command.Format(f)))
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Program is a command file loaded with its includes and procedures resolved
+type Program struct {
+	// File is the syntax tree of the loaded file, included files are
+	// reachable through INCLUDE statements
+	File *File
+	// Procs maps procedure names to their DEF statements
+	Procs map[string]*Node
+	// Cmds are the compiled commands in execution order
+	Cmds []Command
+
+	procCmds map[string][]Command
+}
+
+type scanConfig struct {
+	registry *Registry
+	readFile func(fileName string) ([]byte, error)
+}
+
+// ScanOption is an option that can be passed to `ScanCommandList` and `Load`
+type ScanOption func(*scanConfig)
+
+// WithRegistry provides an option to scan commands of a custom registry
+func WithRegistry(r *Registry) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.registry = r
+	}
+}
+
+// WithReadFile provides an option to read command files from a custom
+// source, e.g. unsaved editor buffers
+func WithReadFile(readFile func(fileName string) ([]byte, error)) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.readFile = readFile
+	}
+}
+
+// ScanCommandList parses commands from the text file, INCLUDE directives are
+// resolved relative to the including file
+func ScanCommandList(fileName string, opts ...ScanOption) ([]Command, error) {
+	prog, err := Load(fileName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return prog.Cmds, nil
+}
+
+// Load parses the command file, resolves INCLUDE directives relative to the
+// including file, validates command arguments and compiles the commands. The
+// program is returned alongside the errors whenever the file could be read so
+// that its syntax tree can still be inspected
+func Load(fileName string, opts ...ScanOption) (*Program, error) {
+	cfg := &scanConfig{
+		registry: DefaultRegistry,
+		readFile: os.ReadFile,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	l := &loader{
+		scanConfig: cfg,
+		prog: &Program{
+			Procs:    map[string]*Node{},
+			procCmds: map[string][]Command{},
+		},
+		declared: map[string]bool{},
+		files:    map[string]*loadedFile{},
+	}
+
+	f, err := l.loadFile(fileName, nil)
+	if f == nil {
+		return nil, err
+	}
+	if err != nil {
+		l.errs = append(l.errs, err.(ScanErrors)...)
+	}
+
+	l.prog.File = f
+	l.collectProcs(f.Nodes)
+	l.prog.Cmds = l.compile(f.Nodes)
+	l.checkRecursion()
+
+	return l.prog, l.errs.Err()
+}
+
+type loader struct {
+	*scanConfig
+	prog     *Program
+	declared map[string]bool
+	errs     ScanErrors
+	// via holds positions of INCLUDE statements leading to the statements
+	// that are being compiled
+	via []Pos
+	// files holds the loaded files by absolute path, a file included more
+	// than once is parsed once so that its procedures are defined once
+	files map[string]*loadedFile
+}
+
+type loadedFile struct {
+	f   *File
+	err error
+}
+
+// fail records the error of a statement, errors in included files are
+// wrapped with the positions of the INCLUDE statements leading to them
+func (l *loader) fail(pos Pos, err error) {
+	err = &ScanError{Pos: pos, Err: err}
+	for i := len(l.via) - 1; i >= 0; i-- {
+		err = &ScanError{Pos: l.via[i], Err: err}
+	}
+	l.errs = append(l.errs, err.(*ScanError))
+}
+
+// loadFile parses the command file and the files it includes, stack holds
+// absolute paths of the files that are currently being loaded and is used to
+// detect cycles. Files that were loaded before are not parsed again
+func (l *loader) loadFile(fileName string, stack []string) (*File, error) {
+	absName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving file path: %s", err)
+	}
+	for _, name := range stack {
+		if name == absName {
+			return nil, fmt.Errorf("%w: '%s' is already being scanned", ErrIncludeCycle, fileName)
+		}
+	}
+	if loaded, ok := l.files[absName]; ok {
+		return loaded.f, loaded.err
+	}
+	stack = append(stack[:len(stack):len(stack)], absName)
+
+	src, err := l.readFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening file: %s", err)
+	}
+
+	var errs ScanErrors
+	f, err := Parse(fileName, src)
+	if err != nil {
+		errs = append(errs, err.(ScanErrors)...)
+	}
+
+	walk(f.Nodes, func(n *Node) {
+		if n.Name != "INCLUDE" {
+			return
+		}
+		includeName := n.Params[0]
+		if !filepath.IsAbs(includeName) {
+			includeName = filepath.Join(filepath.Dir(fileName), includeName)
+		}
+		n.Include, err = l.loadFile(includeName, stack)
+		if err != nil {
+			errs = append(errs, &ScanError{Pos: n.Pos, Err: err})
+		}
+	})
+
+	l.files[absName] = &loadedFile{f: f, err: errs.Err()}
+	return f, errs.Err()
+}
+
+// collectProcs registers procedures defined in the statements and the files
+// they include
+func (l *loader) collectProcs(nodes []*Node) {
+	for _, n := range nodes {
+		switch n.Name {
+		case "INCLUDE":
+			if n.Include != nil {
+				l.via = append(l.via, n.Pos)
+				l.collectProcs(n.Include.Nodes)
+				l.via = l.via[:len(l.via)-1]
+			}
+		case "DEF":
+			name := n.Params[0]
+			if def, ok := l.prog.Procs[name]; ok && def != n {
+				l.fail(n.Pos, fmt.Errorf("procedure %s is already defined at %s", name, def.Pos))
+				continue
+			}
+			l.prog.Procs[name] = n
+		}
+	}
+}
+
+// compile validates the statements in the order they appear and returns the
+// commands to be executed, procedure bodies are compiled separately
+func (l *loader) compile(nodes []*Node) []Command {
+	cmds := []Command{}
+	for _, n := range nodes {
+		switch n.Name {
+		case "INCLUDE":
+			if n.Include != nil {
+				l.via = append(l.via, n.Pos)
+				cmds = append(cmds, l.compile(n.Include.Nodes)...)
+				l.via = l.via[:len(l.via)-1]
+			}
+		case "DEF":
+			if l.prog.Procs[n.Params[0]] == n {
+				l.prog.procCmds[n.Params[0]] = l.compile(n.Body)
+			}
+		case "CALL":
+			cmd, err := l.callCmd(n)
+			if err != nil {
+				l.fail(n.Pos, err)
+				continue
+			}
+			cmds = append(cmds, cmd.at(n.Pos))
+		case "REPEAT":
+			cmd, err := l.repeatCmd(n)
+			if err != nil {
+				l.fail(n.Pos, err)
+				continue
+			}
+			cmds = append(cmds, cmd.at(n.Pos))
+		default:
+			cmd, err := l.registry.compile(n, l.declared)
+			if err != nil {
+				l.fail(n.Pos, err)
+				continue
+			}
+			cmds = append(cmds, cmd.at(n.Pos))
+		}
+	}
+	return cmds
+}
+
+// callCmd deserialize procedure call
+func (l *loader) callCmd(n *Node) (Command, error) {
+	if len(n.Params) != 1 {
+		return nil, fmt.Errorf("CALL command requires 1 parameters, but %d were detected", len(n.Params))
+	}
+	name := n.Params[0]
+	if _, ok := l.prog.Procs[name]; !ok {
+		return nil, fmt.Errorf("undefined procedure detected: '%s'", name)
+	}
+
+	return func(t Table, env *Env) error {
+		return env.exec(t, l.prog.procCmds[name])
+	}, nil
+}
+
+// repeatCmd deserialize loop executing the block the given number of times,
+// the block is compiled even when the count is invalid so that its errors are
+// reported as well
+func (l *loader) repeatCmd(n *Node) (Command, error) {
+	if len(n.Params) != 1 {
+		l.compile(n.Body)
+		return nil, fmt.Errorf("REPEAT command requires 1 parameters, but %d were detected", len(n.Params))
+	}
+	count, err := parseExpr(n.Params[0], l.declared)
+	if err != nil {
+		l.compile(n.Body)
+		return nil, fmt.Errorf("count parameter is not a valid expression(%s): %w", n.Params[0], err)
+	}
+	n.Args = []Value{{Raw: n.Params[0], Expr: count}}
+	body := l.compile(n.Body)
+
+	return func(t Table, env *Env) error {
+		times, err := env.Eval(t, count)
+		if err != nil {
+			return err
+		}
+		if times < 0 {
+			return fmt.Errorf("REPEAT count is negative(%d)", times)
+		}
+		for i := 0; i < times; i++ {
+			// an empty body executes no command so its iterations are taken
+			// as steps, otherwise neither the context nor the budget could
+			// stop the loop
+			if len(body) == 0 {
+				if err := env.step(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := env.exec(t, body); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// checkRecursion reports procedures that call themselves directly or through
+// other procedures as they would never finish
+func (l *loader) checkRecursion() {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		walk(l.prog.Procs[name].Body, func(n *Node) {
+			if n.Name != "CALL" || len(n.Params) != 1 {
+				return
+			}
+			callee := n.Params[0]
+			if _, ok := l.prog.Procs[callee]; !ok {
+				return
+			}
+			switch state[callee] {
+			case visiting:
+				l.fail(n.Pos, fmt.Errorf("recursive call of procedure %s detected", callee))
+			case unvisited:
+				visit(callee)
+			}
+		})
+		state[name] = visited
+	}
+
+	for _, name := range l.prog.sortedProcs() {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+}
+
+// Walk calls fn for every statement of the program including statements
+// nested in blocks and statements of included files
+func (p *Program) Walk(fn func(n *Node)) {
+	walkProgram(p.File.Nodes, fn)
+}
+
+// sortedProcs returns procedure names in the order they were defined
+func (p *Program) sortedProcs() []string {
+	names := []string{}
+	walkProgram(p.File.Nodes, func(n *Node) {
+		if n.Name == "DEF" && p.Procs[n.Params[0]] == n {
+			names = append(names, n.Params[0])
+		}
+	})
+	return names
+}
+
+// walk calls fn for every statement including statements nested in blocks
+func walk(nodes []*Node, fn func(n *Node)) {
+	for _, n := range nodes {
+		fn(n)
+		walk(n.Body, fn)
+	}
+}
+
+// walkProgram calls fn for every statement including statements nested in
+// blocks and statements of included files
+func walkProgram(nodes []*Node, fn func(n *Node)) {
+	walk(nodes, func(n *Node) {
+		fn(n)
+		if n.Include != nil {
+			walkProgram(n.Include.Nodes, fn)
+		}
+	})
+}
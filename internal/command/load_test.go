@@ -0,0 +1,177 @@
+package command_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/table"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		commandFile    string
+		expectedProcs  []string
+		expectedReport string
+		expectedErrs   []string
+	}{
+		{
+			name:           "should load and run commands calling procedures",
+			commandFile:    "./fixtures/procs.txt",
+			expectedProcs:  []string{"SQUARE_SIDE"},
+			expectedReport: "Robot position: (2, 2) facing: WEST\nRobot position: (0, 0) facing: EAST\n",
+		},
+		{
+			name:           "should define procedures of a file included twice once",
+			commandFile:    "./fixtures/include/diamond/main.txt",
+			expectedProcs:  []string{"STEP"},
+			expectedReport: "Robot position: (0, 2) facing: NORTH\n",
+		},
+		{
+			name:          "should fail to load recursive procedures",
+			commandFile:   "./fixtures/recursive.txt",
+			expectedProcs: []string{"A", "B"},
+			expectedErrs: []string{
+				"./fixtures/recursive.txt:5: recursive call of procedure A detected",
+			},
+		},
+		{
+			name:          "should report every error of the command file",
+			commandFile:   "./fixtures/errors.txt",
+			expectedProcs: []string{"X"},
+			expectedErrs: []string{
+				"./fixtures/errors.txt:7: END without matching DEF or REPEAT",
+				"./fixtures/errors.txt:8: procedure X is already defined at ./fixtures/errors.txt:4",
+				"./fixtures/errors.txt:2: invalid command detected: 'JUMP'",
+				"./fixtures/errors.txt:3: undefined procedure detected: 'NOWHERE'",
+				"./fixtures/errors.txt:5: invalid mode parameter detected(), expected one of PARTIAL",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			prog, err := command.Load(tt.commandFile)
+			require.NotNil(t, prog)
+
+			procs := []string{}
+			for name := range prog.Procs {
+				procs = append(procs, name)
+			}
+			require.ElementsMatch(t, tt.expectedProcs, procs)
+
+			if len(tt.expectedErrs) > 0 {
+				var errs command.ScanErrors
+				require.ErrorAs(t, err, &errs)
+				msgs := []string{}
+				for _, err := range errs {
+					msgs = append(msgs, err.Error())
+				}
+				require.Equal(t, tt.expectedErrs, msgs)
+
+				var first *command.ScanError
+				require.ErrorAs(t, err, &first)
+				require.Equal(t, tt.expectedErrs[0], first.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			reportBuf := bytes.NewBufferString("")
+			err = command.Run(table.New(5, 5, table.WithReportOutput(reportBuf)), prog.Cmds)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedReport, reportBuf.String())
+		})
+	}
+}
+
+func TestRunStepHook(t *testing.T) {
+	t.Parallel()
+
+	prog, err := command.Load("./fixtures/procs.txt")
+	require.NoError(t, err)
+
+	lines := []int{}
+	refused := 0
+	err = command.Run(table.New(2, 2, table.WithReportOutput(io.Discard)), prog.Cmds, command.WithStepHook(func(pos command.Pos, t command.Table, err error) {
+		lines = append(lines, pos.Line)
+		if err != nil {
+			refused++
+		}
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []int{5, 2, 3, 6, 2, 3, 7, 8, 2, 3, 9, 2, 3, 10, 11}, lines)
+	require.Equal(t, 4, refused)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	f, err := command.Parse("inline.txt", []byte("def turn_around\nLEFT\nleft\nend\nINCLUDE \"Setup.txt\"\nplace 1, 2,north\r\n"))
+	require.NoError(t, err)
+	require.Len(t, f.Nodes, 3)
+
+	def := f.Nodes[0]
+	require.Equal(t, "DEF", def.Name)
+	require.Equal(t, []string{"TURN_AROUND"}, def.Params)
+	require.Equal(t, command.Pos{File: "inline.txt", Line: 4}, def.End)
+	require.Len(t, def.Body, 2)
+	require.Equal(t, "LEFT", def.Body[1].Name)
+
+	require.Equal(t, []string{"Setup.txt"}, f.Nodes[1].Params)
+	require.Equal(t, "PLACE", f.Nodes[2].Name)
+	require.Equal(t, []string{"1", "2", "NORTH"}, f.Nodes[2].Params)
+	require.Equal(t, 6, f.Nodes[2].Pos.Line)
+}
+
+func TestParseBlocks(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		src         string
+		expectedErr string
+	}{
+		{
+			name: "should parse loops nested in procedures",
+			src:  "DEF P\n  REPEAT 2\n    REPEAT 3\n      MOVE\n    END\n  END\nEND\n",
+		},
+		{
+			name:        "should fail to parse procedure nested in loop",
+			src:         "REPEAT 2\n  DEF P\n  END\nEND\n",
+			expectedErr: "inline.txt:2: DEF P can not be nested in REPEAT\ninline.txt:4: END without matching DEF or REPEAT",
+		},
+		{
+			name:        "should fail to parse include in loop",
+			src:         "REPEAT 2\n  INCLUDE \"a.txt\"\nEND\n",
+			expectedErr: "inline.txt:2: INCLUDE can not be used in REPEAT",
+		},
+		{
+			name:        "should fail to parse loops missing end",
+			src:         "REPEAT 2\n  REPEAT 3\n  MOVE\nEND\n",
+			expectedErr: "inline.txt:1: REPEAT is missing END",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := command.Parse("inline.txt", []byte(tt.src))
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"robot/internal/expr"
+)
+
+// File is the syntax tree of a single command file
+type File struct {
+	Name  string
+	Nodes []*Node
+	// Comments holds comments occupying whole lines in the order they appear
+	Comments []*Comment
+}
+
+// Comment is a comment starting with '#' and running until the end of line
+type Comment struct {
+	Pos Pos
+	// Text is the comment without the leading '#'
+	Text string
+}
+
+// Node is a single statement of a command file
+type Node struct {
+	Pos Pos
+	// Name is the upper case keyword the statement starts with
+	Name string
+	// Params are the trimmed comma separated parameters, they are upper case
+	// except for the file name of INCLUDE statements
+	Params []string
+	// Args are the params validated against the command spec, they are set
+	// once the file is loaded
+	Args []Value
+	// Body holds statements of DEF and REPEAT blocks
+	Body []*Node
+	// End is the position of the END statement closing a block
+	End Pos
+	// Comment is the text of a comment following the statement on its line
+	// and EndComment the one following the END statement of a block
+	Comment    string
+	EndComment string
+	// Include is the included file of INCLUDE statements, it is set once the
+	// file is loaded
+	Include *File
+}
+
+// Parse parses the command file into a syntax tree, includes are not resolved
+// and command arguments are not validated until the file is loaded. Blank
+// lines are skipped and comments are kept so that the file can be formatted
+func Parse(fileName string, src []byte) (*File, error) {
+	f := &File{
+		Name: fileName,
+	}
+
+	var errs ScanErrors
+	// blocks holds the open DEF and REPEAT statements, innermost last
+	var blocks []*Node
+	add := func(n *Node) {
+		if len(blocks) == 0 {
+			f.Nodes = append(f.Nodes, n)
+			return
+		}
+		block := blocks[len(blocks)-1]
+		block.Body = append(block.Body, n)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(src), "\n"), "\n")
+	for i, line := range lines {
+		pos := Pos{File: fileName, Line: i + 1}
+		code, comment, hasComment := splitComment(strings.TrimSuffix(line, "\r"))
+		if strings.TrimSpace(code) == "" {
+			if hasComment {
+				f.Comments = append(f.Comments, &Comment{Pos: pos, Text: comment})
+			}
+			continue
+		}
+
+		n, err := parseNode(code, pos)
+		if err != nil {
+			errs = append(errs, &ScanError{Pos: pos, Err: err})
+			continue
+		}
+
+		n.Comment = comment
+
+		switch n.Name {
+		case "DEF":
+			if len(blocks) > 0 {
+				errs = append(errs, &ScanError{Pos: pos, Err: fmt.Errorf("DEF %s can not be nested in %s", n.Params[0], describeBlock(blocks[len(blocks)-1]))})
+				continue
+			}
+			add(n)
+			blocks = append(blocks, n)
+		case "REPEAT":
+			add(n)
+			blocks = append(blocks, n)
+		case "END":
+			if len(blocks) == 0 {
+				errs = append(errs, &ScanError{Pos: pos, Err: fmt.Errorf("END without matching DEF or REPEAT")})
+				continue
+			}
+			block := blocks[len(blocks)-1]
+			block.End = pos
+			block.EndComment = comment
+			blocks = blocks[:len(blocks)-1]
+		case "INCLUDE":
+			if len(blocks) > 0 {
+				errs = append(errs, &ScanError{Pos: pos, Err: fmt.Errorf("INCLUDE can not be used in %s", describeBlock(blocks[len(blocks)-1]))})
+				continue
+			}
+			add(n)
+		default:
+			add(n)
+		}
+	}
+	for _, block := range blocks {
+		errs = append(errs, &ScanError{Pos: block.Pos, Err: fmt.Errorf("%s is missing END", describeBlock(block))})
+	}
+
+	if len(errs) > 0 {
+		return f, errs
+	}
+	return f, nil
+}
+
+// describeBlock names the block statement in errors
+func describeBlock(n *Node) string {
+	if n.Name == "DEF" {
+		return "DEF " + n.Params[0]
+	}
+	return n.Name
+}
+
+// StripComment returns the line without the comment following the '#' that is
+// not part of a quoted file name, e.g. for lines parsed one at a time
+func StripComment(line string) string {
+	code, _, _ := splitComment(line)
+	return code
+}
+
+// splitComment splits the line into code and comment following the '#' that is
+// not part of a quoted file name
+func splitComment(line string) (code, comment string, ok bool) {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"' && (i == 0 || line[i-1] != '\\'):
+			quoted = !quoted
+		case c == '#' && !quoted:
+			return line[:i], strings.TrimRight(line[i+1:], " \t"), true
+		}
+	}
+	return line, "", false
+}
+
+// parseNode parses a single line into a statement
+func parseNode(src string, pos Pos) (*Node, error) {
+	txt := strings.TrimSpace(src)
+	fields := strings.Fields(txt)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty command detected: '%s'", src)
+	}
+
+	n := &Node{
+		Pos:  pos,
+		Name: strings.ToUpper(fields[0]),
+	}
+	rest := strings.TrimSpace(txt[len(fields[0]):])
+
+	switch n.Name {
+	case "INCLUDE":
+		fileName, err := parseInclude(rest, src)
+		if err != nil {
+			return nil, err
+		}
+		n.Params = []string{fileName}
+		return n, nil
+	case "DEF":
+		name := strings.ToUpper(rest)
+		if !expr.IsIdent(name) {
+			return nil, fmt.Errorf("DEF command requires a procedure name: '%s'", src)
+		}
+		n.Params = []string{name}
+		return n, nil
+	case "END":
+		if rest != "" {
+			return nil, fmt.Errorf("END command requires 0 parameters: '%s'", src)
+		}
+		return n, nil
+	}
+
+	if rest != "" {
+		for _, param := range strings.Split(strings.ToUpper(rest), ",") {
+			n.Params = append(n.Params, strings.TrimSpace(param))
+		}
+	}
+	return n, nil
+}
+
+// parseInclude deserialize the quoted file name of include directive
+func parseInclude(quoted string, src string) (string, error) {
+	if !strings.HasPrefix(quoted, `"`) {
+		return "", fmt.Errorf("INCLUDE command requires a quoted file name: '%s'", src)
+	}
+	fileName, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", fmt.Errorf("INCLUDE command file name is not properly quoted(%s): %s", quoted, err.Error())
+	}
+	if fileName == "" {
+		return "", fmt.Errorf("INCLUDE command requires a non empty file name: '%s'", src)
+	}
+	return fileName, nil
+}
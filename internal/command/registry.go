@@ -0,0 +1,317 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"robot/internal/direction"
+	"robot/internal/expr"
+)
+
+// ArgKind defines what kind of value a command argument holds
+type ArgKind int
+
+const (
+	// ArgExpr is an integer arithmetic expression
+	ArgExpr ArgKind = iota
+	// ArgDirection is one of EAST, NORTH, WEST or SOUTH
+	ArgDirection
+	// ArgKeyword is one of the choices listed by the argument
+	ArgKeyword
+	// ArgAssignment is a variable name and expression in the 'name = expression' form
+	ArgAssignment
+)
+
+// Arg describes a single argument of a command
+type Arg struct {
+	Name     string
+	Kind     ArgKind
+	Choices  []string
+	Optional bool
+}
+
+// Value is an argument that was validated against its schema
+type Value struct {
+	// Raw is the argument as it appeared in the command
+	Raw string
+	// Expr is set for expression and assignment arguments
+	Expr expr.Expr
+	// Direction is set for direction arguments
+	Direction direction.Direction
+	// Name is set to the assigned variable for assignment arguments and to
+	// the chosen keyword for keyword arguments
+	Name string
+}
+
+// Spec describes a command that can be used in command files
+type Spec struct {
+	// Name is the keyword the command starts with
+	Name string
+	// Args is the schema arguments are validated against, arguments are
+	// comma separated and optional ones may only be followed by optional ones
+	Args []Arg
+	// Help is a short description of what the command does
+	Help string
+	// NeedsPlacement tells the command is refused until the robot is placed,
+	// e.g. for linters reporting commands executed before the first PLACE
+	NeedsPlacement bool
+	// Parse is optional and validates arguments beyond what the schema allows
+	Parse func(args []Value) error
+	// Exec executes the command with validated arguments, absent optional
+	// arguments are not passed
+	Exec func(t Table, env *Env, args []Value) error
+}
+
+// Usage returns the command syntax with optional arguments in brackets
+func (s Spec) Usage() string {
+	var sb strings.Builder
+	sb.WriteString(s.Name)
+	for i, arg := range s.Args {
+		name := arg.Name
+		if i > 0 {
+			name = "," + name
+		}
+		if i == 0 || (arg.Optional && !s.Args[i-1].Optional) {
+			sb.WriteString(" ")
+		}
+		if arg.Optional {
+			name = "[" + name + "]"
+		}
+		sb.WriteString(name)
+	}
+	return sb.String()
+}
+
+// reserved keywords are handled by the scanner and can not be registered
+var reserved = map[string]bool{
+	"INCLUDE": true,
+	"DEF":     true,
+	"END":     true,
+	"CALL":    true,
+	"REPEAT":  true,
+}
+
+// Registry maps command names to their specs, it is safe for concurrent use so
+// commands may be registered while other goroutines parse
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[string]Spec
+}
+
+// DefaultRegistry is used when scanning commands unless another registry is
+// provided, it holds the built-in commands
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns registry holding the built-in commands
+func NewRegistry() *Registry {
+	r := &Registry{
+		specs: map[string]Spec{},
+	}
+	for _, spec := range builtinSpecs {
+		if err := r.Register(spec); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds the command to the default registry
+func Register(spec Spec) error {
+	return DefaultRegistry.Register(spec)
+}
+
+// Register adds the command to the registry, it fails when the spec is
+// invalid or its name is already taken
+func (r *Registry) Register(spec Spec) error {
+	name := strings.ToUpper(spec.Name)
+	if !expr.IsIdent(name) {
+		return fmt.Errorf("invalid command name: '%s'", spec.Name)
+	}
+	if reserved[name] {
+		return fmt.Errorf("command name %s is reserved", name)
+	}
+	if spec.Exec == nil {
+		return fmt.Errorf("command %s has no executor", name)
+	}
+
+	optional := false
+	for _, arg := range spec.Args {
+		if optional && !arg.Optional {
+			return fmt.Errorf("command %s has required argument %s after optional one", name, arg.Name)
+		}
+		if arg.Kind == ArgKeyword && len(arg.Choices) == 0 {
+			return fmt.Errorf("command %s keyword argument %s has no choices", name, arg.Name)
+		}
+		optional = arg.Optional
+	}
+
+	spec.Name = name
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.specs[name]; ok {
+		return fmt.Errorf("command %s is already registered", name)
+	}
+	r.specs[name] = spec
+	return nil
+}
+
+// Lookup returns spec of the command with the given name
+func (r *Registry) Lookup(name string) (Spec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[strings.ToUpper(name)]
+	return spec, ok
+}
+
+// Specs returns all registered commands sorted by name
+func (r *Registry) Specs() []Spec {
+	r.mu.RLock()
+	specs := make([]Spec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	r.mu.RUnlock()
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// Help returns usage and description of every registered command
+func (r *Registry) Help() string {
+	var sb strings.Builder
+	for _, spec := range r.Specs() {
+		fmt.Fprintf(&sb, "%s\n    %s\n", spec.Usage(), spec.Help)
+	}
+	return sb.String()
+}
+
+// Parse deserialize individual command, expressions may only reference
+// built-in values as there are no variables assigned before it
+func (r *Registry) Parse(src string) (Command, error) {
+	return r.parse(src, map[string]bool{})
+}
+
+// parse deserialize individual command, declared holds names of the
+// variables that were assigned before it and is updated by assignments
+func (r *Registry) parse(src string, declared map[string]bool) (Command, error) {
+	n, err := parseNode(src, Pos{})
+	if err != nil {
+		return nil, err
+	}
+	if reserved[n.Name] {
+		return nil, fmt.Errorf("%s command is only supported in command files: '%s'", n.Name, src)
+	}
+	return r.compile(n, declared)
+}
+
+// compile validates statement arguments and returns the executable command,
+// declared holds names of the variables that were assigned before it and is
+// updated by assignments
+func (r *Registry) compile(n *Node, declared map[string]bool) (Command, error) {
+	spec, ok := r.Lookup(n.Name)
+	if !ok {
+		return nil, fmt.Errorf("invalid command detected: '%s'", n.Name)
+	}
+
+	args, err := spec.validate(n.Params, declared)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Parse != nil {
+		if err := spec.Parse(args); err != nil {
+			return nil, err
+		}
+	}
+	n.Args = args
+
+	return func(t Table, env *Env) error {
+		return spec.Exec(t, env, args)
+	}, nil
+}
+
+// validate checks parameters against the argument schema
+func (s Spec) validate(params []string, declared map[string]bool) ([]Value, error) {
+	required := 0
+	for _, arg := range s.Args {
+		if !arg.Optional {
+			required++
+		}
+	}
+	if len(params) < required || len(params) > len(s.Args) {
+		if required == len(s.Args) {
+			return nil, fmt.Errorf("%s command requires %d parameters, but %d were detected", s.Name, required, len(params))
+		}
+		return nil, fmt.Errorf("%s command requires %d to %d parameters, but %d were detected", s.Name, required, len(s.Args), len(params))
+	}
+
+	args := make([]Value, 0, len(params))
+	for i, param := range params {
+		arg := s.Args[i]
+		v := Value{Raw: param}
+
+		switch arg.Kind {
+		case ArgExpr:
+			x, err := parseExpr(v.Raw, declared)
+			if err != nil {
+				return nil, fmt.Errorf("%s parameter is not a valid expression(%s): %w", arg.Name, v.Raw, err)
+			}
+			v.Expr = x
+		case ArgDirection:
+			d, ok := directions[v.Raw]
+			if !ok {
+				return nil, fmt.Errorf("invalid %s parameter detected(%s)", arg.Name, v.Raw)
+			}
+			v.Direction = d
+		case ArgKeyword:
+			for _, choice := range arg.Choices {
+				if v.Raw == choice {
+					v.Name = choice
+				}
+			}
+			if v.Name == "" {
+				return nil, fmt.Errorf("invalid %s parameter detected(%s), expected one of %s", arg.Name, v.Raw, strings.Join(arg.Choices, ", "))
+			}
+		case ArgAssignment:
+			eq := strings.Index(v.Raw, "=")
+			if eq < 0 {
+				return nil, fmt.Errorf("%s parameter requires 'name = expression' form(%s)", arg.Name, v.Raw)
+			}
+			v.Name = strings.TrimSpace(v.Raw[:eq])
+			if !expr.IsIdent(v.Name) {
+				return nil, fmt.Errorf("invalid variable name detected(%s)", v.Name)
+			}
+			if _, ok := builtinValues[v.Name]; ok {
+				return nil, fmt.Errorf("built-in value %s can not be assigned", v.Name)
+			}
+			x, err := parseExpr(v.Raw[eq+1:], declared)
+			if err != nil {
+				return nil, fmt.Errorf("value of %s is not a valid expression(%s): %w", v.Name, strings.TrimSpace(v.Raw[eq+1:]), err)
+			}
+			v.Expr = x
+		default:
+			return nil, errors.New("unknown argument kind")
+		}
+		args = append(args, v)
+	}
+
+	// assignments are declared only once the whole command is valid so that
+	// 'SET x = x + 1' can not reference itself before x was ever assigned
+	for _, v := range args {
+		if v.Name != "" && v.Expr != nil {
+			declared[v.Name] = true
+		}
+	}
+	return args, nil
+}
+
+// directions maps direction names to directions
+var directions = map[string]direction.Direction{
+	direction.East.String():  direction.East,
+	direction.North.String(): direction.North,
+	direction.West.String():  direction.West,
+	direction.South.String(): direction.South,
+}
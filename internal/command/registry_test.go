@@ -0,0 +1,220 @@
+package command_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/table"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	exec := func(t command.Table, env *command.Env, args []command.Value) error {
+		return nil
+	}
+
+	tests := [...]struct {
+		name      string
+		spec      command.Spec
+		shouldErr bool
+	}{
+		{
+			name: "should register custom command",
+			spec: command.Spec{
+				Name: "beep",
+				Args: []command.Arg{{Name: "times", Kind: command.ArgExpr, Optional: true}},
+				Exec: exec,
+			},
+			shouldErr: false,
+		},
+		{
+			name:      "should fail to register command with taken name",
+			spec:      command.Spec{Name: "MOVE", Exec: exec},
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to register reserved command",
+			spec:      command.Spec{Name: "INCLUDE", Exec: exec},
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to register command with invalid name",
+			spec:      command.Spec{Name: "GO TO", Exec: exec},
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to register command without executor",
+			spec:      command.Spec{Name: "DOCK"},
+			shouldErr: true,
+		},
+		{
+			name: "should fail to register command with required argument after optional one",
+			spec: command.Spec{
+				Name: "SCAN",
+				Args: []command.Arg{
+					{Name: "range", Kind: command.ArgExpr, Optional: true},
+					{Name: "facing", Kind: command.ArgDirection},
+				},
+				Exec: exec,
+			},
+			shouldErr: true,
+		},
+		{
+			name: "should fail to register keyword argument without choices",
+			spec: command.Spec{
+				Name: "SCAN",
+				Args: []command.Arg{{Name: "mode", Kind: command.ArgKeyword}},
+				Exec: exec,
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := command.NewRegistry().Register(tt.spec)
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRegistryParse(t *testing.T) {
+	t.Parallel()
+
+	var scanned []string
+	reg := command.NewRegistry()
+	err := reg.Register(command.Spec{
+		Name: "SCAN",
+		Args: []command.Arg{
+			{Name: "range", Kind: command.ArgExpr},
+			{Name: "facing", Kind: command.ArgDirection, Optional: true},
+			{Name: "mode", Kind: command.ArgKeyword, Choices: []string{"FAST", "SLOW"}, Optional: true},
+		},
+		Help: "scans the surroundings",
+		Parse: func(args []command.Value) error {
+			if args[0].Raw == "0" {
+				return fmt.Errorf("range can not be zero")
+			}
+			return nil
+		},
+		Exec: func(t command.Table, env *command.Env, args []command.Value) error {
+			n, err := env.Eval(t, args[0].Expr)
+			if err != nil {
+				return err
+			}
+			line := fmt.Sprint(n)
+			for _, arg := range args[1:] {
+				line += " " + arg.Raw
+			}
+			scanned = append(scanned, line)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	tests := [...]struct {
+		name      string
+		command   string
+		shouldErr bool
+	}{
+		{name: "should parse command with required arguments", command: "SCAN WIDTH"},
+		{name: "should parse command with optional arguments", command: "scan 2, north, fast"},
+		{name: "should fail to parse command with too many arguments", command: "SCAN 1,NORTH,FAST,1", shouldErr: true},
+		{name: "should fail to parse command with missing arguments", command: "SCAN", shouldErr: true},
+		{name: "should fail to parse invalid direction argument", command: "SCAN 1,UP", shouldErr: true},
+		{name: "should fail to parse invalid keyword argument", command: "SCAN 1,EAST,MEDIUM", shouldErr: true},
+		{name: "should fail to parse invalid expression argument", command: "SCAN 1+", shouldErr: true},
+		{name: "should fail custom validation", command: "SCAN 0", shouldErr: true},
+		{name: "should fail to parse arguments of command without any", command: "LEFT 1", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := reg.Parse(tt.command)
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	_, ok := command.DefaultRegistry.Lookup("SCAN")
+	require.False(t, ok)
+	spec, ok := reg.Lookup("scan")
+	require.True(t, ok)
+	require.Equal(t, "SCAN range [,facing][,mode]", spec.Usage())
+	move, ok := reg.Lookup("MOVE")
+	require.True(t, ok)
+	require.Equal(t, "MOVE [steps][,mode]", move.Usage())
+	require.Contains(t, reg.Help(), "SCAN range [,facing][,mode]\n    scans the surroundings\n")
+
+	reportBuf := bytes.NewBufferString("")
+	cmds, err := command.ScanCommandList("./fixtures/scan.txt", command.WithRegistry(reg))
+	require.NoError(t, err)
+	err = command.Run(table.New(5, 5, table.WithReportOutput(reportBuf)), cmds)
+	require.NoError(t, err)
+	require.Equal(t, []string{"4", "3 SOUTH SLOW"}, scanned)
+	require.Equal(t, "Robot position: (0, 0) facing: NORTH\n", reportBuf.String())
+
+	_, err = command.ScanCommandList("./fixtures/scan.txt")
+	require.Error(t, err)
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	t.Parallel()
+
+	r := command.NewRegistry()
+	exec := func(t command.Table, env *command.Env, args []command.Value) error {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, r.Register(command.Spec{Name: fmt.Sprintf("BEEP%d", i), Exec: exec}))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := r.Parse("MOVE 2")
+			require.NoError(t, err)
+			r.Help()
+		}()
+	}
+	wg.Wait()
+	require.Len(t, r.Specs(), len(command.NewRegistry().Specs())+8)
+}
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+
+	scope := command.DefaultRegistry.NewScope()
+	reportBuf := bytes.NewBufferString("")
+	tbl := table.New(5, 5, table.WithReportOutput(reportBuf))
+
+	_, err := scope.Parse("PLACE x,0,NORTH")
+	require.Error(t, err)
+
+	for _, src := range []string{"SET x = 2", "SET x = x + 1", "PLACE x,x,EAST", "REPORT"} {
+		cmd, err := scope.Parse(src)
+		require.NoError(t, err)
+		require.NoError(t, command.Run(tbl, []command.Command{cmd}, command.WithScope(scope)))
+	}
+	require.Equal(t, "Robot position: (3, 3) facing: EAST\n", reportBuf.String())
+}
@@ -9,7 +9,11 @@ type tableMock struct {
 	placeRobotFn  func(pos point.Point, facing direction.Direction) error
 	rotateRobotFn func(left bool) (*direction.Direction, error)
 	moveRobotFn   func() (*point.Point, error)
+	moveRobotByFn func(steps int, partial bool) (*point.Point, error)
+	turnRobotFn   func(degrees int) (*direction.Direction, error)
 	reportFn      func() error
+	sizeFn        func() (uint, uint)
+	robotFn       func() (point.Point, direction.Direction, error)
 	fnCnt         map[string]int
 }
 
@@ -44,6 +48,22 @@ func (m *tableMock) MoveRobot() (*point.Point, error) {
 	return &point.Point{X: 0, Y: 0}, nil
 }
 
+func (m *tableMock) MoveRobotBy(steps int, partial bool) (*point.Point, error) {
+	m.funcCallCountInc("MoveRobotBy")
+	if m.moveRobotByFn != nil {
+		return m.moveRobotByFn(steps, partial)
+	}
+	return &point.Point{X: 0, Y: 0}, nil
+}
+
+func (m *tableMock) TurnRobot(degrees int) (*direction.Direction, error) {
+	m.funcCallCountInc("TurnRobot")
+	if m.turnRobotFn != nil {
+		return m.turnRobotFn(degrees)
+	}
+	return &direction.North, nil
+}
+
 func (m *tableMock) Report() error {
 	m.funcCallCountInc("Report")
 	if m.reportFn != nil {
@@ -51,3 +71,19 @@ func (m *tableMock) Report() error {
 	}
 	return nil
 }
+
+func (m *tableMock) Size() (uint, uint) {
+	m.funcCallCountInc("Size")
+	if m.sizeFn != nil {
+		return m.sizeFn()
+	}
+	return 5, 5
+}
+
+func (m *tableMock) Robot() (point.Point, direction.Direction, error) {
+	m.funcCallCountInc("Robot")
+	if m.robotFn != nil {
+		return m.robotFn()
+	}
+	return point.Point{X: 0, Y: 0}, direction.North, nil
+}
@@ -0,0 +1,246 @@
+package cover
+
+import (
+	"fmt"
+	"strings"
+
+	"robot/internal/direction"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// Strategy decides in which order cells are visited
+type Strategy int
+
+const (
+	// Boustrophedon sweeps the table row by row changing the direction at
+	// the end of each row
+	Boustrophedon Strategy = iota
+	// Spiral moves straight as long as it can and prefers turning right,
+	// following the edge of the unvisited area inwards
+	Spiral
+)
+
+// Strategies are all coverage strategies
+var Strategies = []Strategy{Boustrophedon, Spiral}
+
+// String returns the upper case name of Strategy
+func (s Strategy) String() string {
+	if s == Spiral {
+		return "SPIRAL"
+	}
+	return "BOUSTROPHEDON"
+}
+
+// ParseStrategy returns the strategy of the case insensitive name
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if s.String() == strings.ToUpper(name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid coverage strategy: '%s'", name)
+}
+
+// Result is the coverage plan and its statistics
+type Result struct {
+	Steps []plan.Step
+	// Reachable is the number of cells reachable from the start including
+	// the start itself
+	Reachable int
+	// Visited is the number of distinct cells visited including the start
+	Visited int
+	// Revisits is the number of moves onto cells that were already visited
+	Revisits int
+	Moves    int
+	// Turns is the number of quarter turns, turning around counts twice
+	Turns int
+}
+
+// Coverage returns the percentage of reachable cells that were visited
+func (r *Result) Coverage() float64 {
+	if r.Reachable == 0 {
+		return 0
+	}
+	return 100 * float64(r.Visited) / float64(r.Reachable)
+}
+
+// String returns the statistics of Result
+func (r *Result) String() string {
+	return fmt.Sprintf("coverage %.1f%% (%d/%d cells), %d revisits, %d moves, %d turns", r.Coverage(), r.Visited, r.Reachable, r.Revisits, r.Moves, r.Turns)
+}
+
+// Plan returns steps visiting every cell reachable from the start without
+// leaving the grid or entering blocked cells. The strategy picks the next
+// neighbouring cell while there are unvisited ones around the robot, once
+// there are none it takes the shortest path to the closest unvisited cell
+func Plan(g plan.Grid, start table.Pose, strategy Strategy) (*Result, error) {
+	if !free(g, start.Pos) {
+		return nil, fmt.Errorf("invalid start %s: %w", start, plan.Validate(g, start.Pos))
+	}
+
+	c := &coverer{
+		grid:     g,
+		pose:     start,
+		visited:  map[point.Point]bool{start.Pos: true},
+		strategy: strategy,
+		sweep:    direction.East,
+		advance:  direction.North,
+		res: &Result{
+			Steps:     []plan.Step{},
+			Reachable: len(reachable(g, start.Pos)),
+			Visited:   1,
+		},
+	}
+	if start.Facing == direction.West {
+		c.sweep = direction.West
+	}
+	if _, sizeY := g.Size(); start.Pos.Y >= int(sizeY)/2 {
+		c.advance = direction.South
+	}
+
+	for c.res.Visited < c.res.Reachable {
+		if d, ok := c.next(); ok {
+			c.head(d)
+			continue
+		}
+
+		target, ok := c.closestUnvisited()
+		if !ok {
+			break
+		}
+		steps, err := plan.Plan(g, c.pose, plan.Goal{Pos: target}, plan.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range steps {
+			c.apply(step)
+		}
+	}
+	return c.res, nil
+}
+
+type coverer struct {
+	grid     plan.Grid
+	pose     table.Pose
+	visited  map[point.Point]bool
+	strategy Strategy
+	// sweep is the direction the current row is swept in and advance the
+	// direction of the next row
+	sweep   direction.Direction
+	advance direction.Direction
+	res     *Result
+}
+
+// next returns the direction of the unvisited neighbour the strategy prefers
+func (c *coverer) next() (direction.Direction, bool) {
+	var candidates []direction.Direction
+	switch c.strategy {
+	case Spiral:
+		right, left := c.pose.Facing, c.pose.Facing
+		right.RotateRight()
+		left.RotateLeft()
+		candidates = []direction.Direction{c.pose.Facing, right, left, c.pose.Facing.Opposite()}
+	default:
+		candidates = []direction.Direction{c.sweep, c.advance, c.sweep.Opposite(), c.advance.Opposite()}
+	}
+
+	for _, d := range candidates {
+		next := d.Step(c.pose.Pos)
+		if free(c.grid, next) && !c.visited[next] {
+			if c.strategy == Boustrophedon && d != c.sweep {
+				// the next row is swept in the opposite direction
+				c.sweep = c.sweep.Opposite()
+			}
+			return d, true
+		}
+	}
+	return direction.Direction{}, false
+}
+
+// head turns the robot towards the direction and moves it by one step
+func (c *coverer) head(d direction.Direction) {
+	left, right := c.pose.Facing, c.pose.Facing
+	left.RotateLeft()
+	right.RotateRight()
+
+	switch d {
+	case left:
+		c.apply(plan.Left)
+	case right:
+		c.apply(plan.Right)
+	case c.pose.Facing.Opposite():
+		c.apply(plan.UTurn)
+	}
+	c.apply(plan.Move)
+}
+
+// apply records the step and updates the pose and the statistics
+func (c *coverer) apply(s plan.Step) {
+	c.res.Steps = append(c.res.Steps, s)
+	switch s {
+	case plan.Move:
+		c.pose.Pos = c.pose.Facing.Step(c.pose.Pos)
+		c.res.Moves++
+		if c.visited[c.pose.Pos] {
+			c.res.Revisits++
+			return
+		}
+		c.visited[c.pose.Pos] = true
+		c.res.Visited++
+	case plan.Left:
+		c.pose.Facing.RotateLeft()
+		c.res.Turns++
+	case plan.Right:
+		c.pose.Facing.RotateRight()
+		c.res.Turns++
+	case plan.UTurn:
+		c.pose.Facing = c.pose.Facing.Opposite()
+		c.res.Turns += 2
+	}
+}
+
+// closestUnvisited returns the unvisited cell with the shortest path from
+// the robot, the search stops at the first one so that only cells closer than
+// it are expanded instead of every reachable cell
+func (c *coverer) closestUnvisited() (point.Point, bool) {
+	cells := []point.Point{c.pose.Pos}
+	seen := map[point.Point]bool{c.pose.Pos: true}
+	for i := 0; i < len(cells); i++ {
+		for _, d := range direction.All() {
+			next := d.Step(cells[i])
+			if !free(c.grid, next) || seen[next] {
+				continue
+			}
+			if !c.visited[next] {
+				return next, true
+			}
+			seen[next] = true
+			cells = append(cells, next)
+		}
+	}
+	return point.Point{}, false
+}
+
+// reachable returns cells reachable from the position ordered by the length
+// of the shortest path to them
+func reachable(g plan.Grid, from point.Point) []point.Point {
+	cells := []point.Point{from}
+	seen := map[point.Point]bool{from: true}
+	for i := 0; i < len(cells); i++ {
+		for _, d := range direction.All() {
+			next := d.Step(cells[i])
+			if free(g, next) && !seen[next] {
+				seen[next] = true
+				cells = append(cells, next)
+			}
+		}
+	}
+	return cells
+}
+
+// free reports whether the robot can be at the position
+func free(g plan.Grid, pos point.Point) bool {
+	return plan.Validate(g, pos) == nil
+}
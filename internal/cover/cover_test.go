@@ -0,0 +1,106 @@
+package cover_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/cover"
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		layout         string
+		start          string
+		strategy       cover.Strategy
+		expectedSource string
+		expectedStats  string
+		expectedErr    error
+	}{
+		{
+			name:           "should sweep empty table row by row",
+			layout:         "...\n...\n...\n",
+			start:          "0,0,NORTH",
+			strategy:       cover.Boustrophedon,
+			expectedSource: "PLACE 0,0,NORTH\nRIGHT\nMOVE 2\nLEFT\nMOVE\nLEFT\nMOVE 2\nRIGHT\nMOVE\nRIGHT\nMOVE 2\n",
+			expectedStats:  "coverage 100.0% (9/9 cells), 0 revisits, 8 moves, 5 turns",
+		},
+		{
+			name:           "should cover empty table in a spiral",
+			layout:         "...\n...\n...\n",
+			start:          "0,2,EAST",
+			strategy:       cover.Spiral,
+			expectedSource: "PLACE 0,2,EAST\nMOVE 2\nRIGHT\nMOVE 2\nRIGHT\nMOVE 2\nRIGHT\nMOVE\nRIGHT\nMOVE\n",
+			expectedStats:  "coverage 100.0% (9/9 cells), 0 revisits, 8 moves, 4 turns",
+		},
+		{
+			name:           "should go around blocked cells",
+			layout:         "...\n.#.\n...\n",
+			start:          "1,0,EAST",
+			strategy:       cover.Boustrophedon,
+			expectedSource: "PLACE 1,0,EAST\nMOVE\nLEFT\nMOVE 2\nLEFT\nMOVE 2\nLEFT\nMOVE 2\n",
+			expectedStats:  "coverage 100.0% (8/8 cells), 0 revisits, 7 moves, 3 turns",
+		},
+		{
+			name:           "should visit only reachable cells",
+			layout:         ".#.\n",
+			start:          "0,0,EAST",
+			strategy:       cover.Spiral,
+			expectedSource: "PLACE 0,0,EAST\n",
+			expectedStats:  "coverage 100.0% (1/1 cells), 0 revisits, 0 moves, 0 turns",
+		},
+		{
+			name:        "should fail to start on blocked cell",
+			layout:      ".#.\n",
+			start:       "1,0,EAST",
+			strategy:    cover.Boustrophedon,
+			expectedErr: table.ErrPositionBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			layout, err := table.ParseLayout([]byte(tt.layout))
+			require.NoError(t, err)
+			start, err := table.ParsePose(tt.start)
+			require.NoError(t, err)
+
+			res, err := cover.Plan(layout, start, tt.strategy)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSource, string(plan.Source(start, res.Steps)))
+			require.Equal(t, tt.expectedStats, res.String())
+		})
+	}
+}
+
+func TestPlanCoversLayouts(t *testing.T) {
+	t.Parallel()
+
+	layout, err := table.ParseLayout([]byte(".....\n.###.\n...#.\n.#...\n.....\n"))
+	require.NoError(t, err)
+
+	for _, strategy := range cover.Strategies {
+		for _, start := range table.Poses(layout.Size()) {
+			if layout.Blocked(start.Pos) {
+				continue
+			}
+
+			res, err := cover.Plan(layout, start, strategy)
+			require.NoError(t, err)
+			require.Equal(t, 20, res.Reachable)
+			require.Equal(t, res.Reachable, res.Visited, "%s from %s", strategy, start)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package direction
 
+import (
+	"fmt"
+
+	"robot/internal/point"
+)
+
 // Direction defines by how much the object would advance alongise X and Y axis
 type Direction struct {
 	dX int
@@ -30,6 +36,22 @@ func (d Direction) String() string {
 	}
 }
 
+// Parse returns direction of the upper case name
+func Parse(name string) (Direction, error) {
+	for _, d := range ccwDirections {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+	return Direction{}, fmt.Errorf("%w: '%s'", ErrInvalidDirection, name)
+}
+
+// All returns all directions in counterclockwise order starting with East
+func All() []Direction {
+	all := ccwDirections
+	return all[:]
+}
+
 // RotateLeft rotates direction by 90 degress counterclockwise
 func (d *Direction) RotateLeft() {
 	for i, dr := range ccwDirections {
@@ -53,6 +75,30 @@ func (d *Direction) RotateRight() {
 	}
 }
 
+// Turn rotates direction by the number of degrees, positive angles rotate it
+// counterclockwise and negative ones clockwise
+func (d *Direction) Turn(degrees int) error {
+	if degrees%90 != 0 {
+		return ErrInvalidAngle
+	}
+
+	quarters := (degrees/90%len(ccwDirections) + len(ccwDirections)) % len(ccwDirections)
+	for i := 0; i < quarters; i++ {
+		d.RotateLeft()
+	}
+	return nil
+}
+
+// Opposite returns direction pointing the other way
+func (d Direction) Opposite() Direction {
+	return Direction{dX: -d.dX, dY: -d.dY}
+}
+
+// Step returns the position next to the position in the direction
+func (d Direction) Step(pos point.Point) point.Point {
+	return point.Point{X: pos.X + d.dX, Y: pos.Y + d.dY}
+}
+
 func (d Direction) DX() int {
 	return d.dX
 }
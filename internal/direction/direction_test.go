@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"robot/internal/direction"
+	"robot/internal/point"
 )
 
 func TestString(t *testing.T) {
@@ -129,3 +130,138 @@ func TestRotateRight(t *testing.T) {
 		})
 	}
 }
+
+func TestTurn(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		src         direction.Direction
+		degrees     int
+		expected    direction.Direction
+		expectedErr error
+	}{
+		{
+			name:     "should turn counterclockwise by positive angle",
+			src:      direction.East,
+			degrees:  90,
+			expected: direction.North,
+		},
+		{
+			name:     "should turn clockwise by negative angle",
+			src:      direction.East,
+			degrees:  -90,
+			expected: direction.South,
+		},
+		{
+			name:     "should turn around by 180 degrees",
+			src:      direction.North,
+			degrees:  180,
+			expected: direction.South,
+		},
+		{
+			name:     "should turn by angles larger than full circle",
+			src:      direction.West,
+			degrees:  -450,
+			expected: direction.North,
+		},
+		{
+			name:        "should fail to turn by angle that is not multiple of 90 degrees",
+			src:         direction.West,
+			degrees:     45,
+			expected:    direction.West,
+			expectedErr: direction.ErrInvalidAngle,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual := tt.src
+			err := actual.Turn(tt.degrees)
+			require.Equal(t, tt.expectedErr, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestOpposite(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, direction.West, direction.East.Opposite())
+	require.Equal(t, direction.South, direction.North.Opposite())
+	require.Equal(t, direction.East, direction.West.Opposite())
+	require.Equal(t, direction.North, direction.South.Opposite())
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		src       string
+		expected  direction.Direction
+		shouldErr bool
+	}{
+		{name: "should parse East", src: "EAST", expected: direction.East},
+		{name: "should parse South", src: "SOUTH", expected: direction.South},
+		{name: "should fail to parse lower case name", src: "north", shouldErr: true},
+		{name: "should fail to parse unknown name", src: "UP", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := direction.Parse(tt.src)
+			if tt.shouldErr {
+				require.ErrorIs(t, err, direction.ErrInvalidDirection)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		src      direction.Direction
+		expected point.Point
+	}{
+		{
+			name:     "should step to the East",
+			src:      direction.East,
+			expected: point.Point{X: 2, Y: 1},
+		},
+		{
+			name:     "should step to the North",
+			src:      direction.North,
+			expected: point.Point{X: 1, Y: 2},
+		},
+		{
+			name:     "should step to the West",
+			src:      direction.West,
+			expected: point.Point{X: 0, Y: 1},
+		},
+		{
+			name:     "should step to the South",
+			src:      direction.South,
+			expected: point.Point{X: 1, Y: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, tt.src.Step(point.Point{X: 1, Y: 1}))
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package direction
+
+import "errors"
+
+var (
+	ErrInvalidAngle     error = errors.New("angle is not a multiple of 90 degrees")
+	ErrInvalidDirection error = errors.New("invalid direction")
+)
@@ -0,0 +1,204 @@
+package equiv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"robot/internal/command"
+	"robot/internal/table"
+)
+
+// DefaultMaxSteps is the step budget of a single run when none is configured
+const DefaultMaxSteps = 100000
+
+// Config holds the table size programs are compared on
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// MaxSteps limits the number of commands executed by a single run of a
+	// program, DefaultMaxSteps is used when zero
+	MaxSteps int
+}
+
+// Outcome is what a program did at the diverging REPORT
+type Outcome struct {
+	// Step is the 1-based index of the executed command that produced the
+	// output, procedure calls and loops are not counted as they only execute
+	// other commands. It is zero when the program produced no output
+	Step int
+	// Output is the REPORT output without the trailing new line
+	Output string
+	// Err is the error the run failed with once there was no more output
+	Err error
+}
+
+func (o Outcome) String() string {
+	switch {
+	case o.Step > 0:
+		return fmt.Sprintf("step %d: %s", o.Step, o.Output)
+	case o.Err != nil:
+		return fmt.Sprintf("failed: %s", o.Err)
+	default:
+		return "no output"
+	}
+}
+
+// Divergence describes the first REPORT two programs differ at
+type Divergence struct {
+	// Start is the pose the robot was placed at before the run, nil when the
+	// robot was not placed
+	Start *table.Pose
+	// Report is the 1-based index of the diverging REPORT output
+	Report int
+	A      Outcome
+	B      Outcome
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("programs diverge at REPORT #%d when starting %s\n  a %s\n  b %s", d.Report, describe(d.Start), d.A, d.B)
+}
+
+// Starts returns every start state of the table, the robot not being placed
+// followed by every pose
+func Starts(sizeX, sizeY uint) []*table.Pose {
+	poses := table.Poses(sizeX, sizeY)
+	starts := make([]*table.Pose, 0, len(poses)+1)
+	starts = append(starts, nil)
+	for i := range poses {
+		starts = append(starts, &poses[i])
+	}
+	return starts
+}
+
+// Check runs both loaded programs from every start state of the table and
+// returns the first divergence of their REPORT outputs, nil when the outputs
+// are identical for every start. A run failing is a divergence only when the
+// other one does not fail after the same output. When no divergence is found
+// but a run exhausted the step budget before its output could be compared,
+// ErrInconclusive is returned
+func Check(a, b *command.Program, cfg Config) (*Divergence, error) {
+	if cfg.MaxSteps == 0 {
+		cfg.MaxSteps = DefaultMaxSteps
+	}
+
+	var inconclusive error
+	for _, start := range Starts(cfg.SizeX, cfg.SizeY) {
+		ra, err := run(a, cfg, start)
+		if err != nil {
+			return nil, err
+		}
+		rb, err := run(b, cfg, start)
+		if err != nil {
+			return nil, err
+		}
+
+		d, ok := diverge(ra, rb)
+		if d != nil {
+			d.Start = start
+			return d, nil
+		}
+		if !ok && inconclusive == nil {
+			inconclusive = fmt.Errorf("%w: a run starting %s exhausted the budget of %d steps", ErrInconclusive, describe(start), cfg.MaxSteps)
+		}
+	}
+	return nil, inconclusive
+}
+
+// describe returns the start as used in messages
+func describe(start *table.Pose) string {
+	if start == nil {
+		return "with the robot not placed"
+	}
+	return "at " + start.String()
+}
+
+// report is a line of the REPORT output
+type report struct {
+	step   int
+	output string
+}
+
+// trace is the REPORT output of a single run
+type trace struct {
+	reports []report
+	err     error
+	// exhausted is set when the run was stopped by the step budget, its
+	// output past the recorded reports is unknown
+	exhausted bool
+}
+
+// diverge compares the traces and returns the divergence without the start,
+// ok is false when the traces agree as far as they go but a run exhausted the
+// step budget so that they can not be compared any further
+func diverge(a, b *trace) (d *Divergence, ok bool) {
+	for i := 0; i < len(a.reports) || i < len(b.reports); i++ {
+		if i < len(a.reports) && i < len(b.reports) && a.reports[i].output == b.reports[i].output {
+			continue
+		}
+		if (i >= len(a.reports) && a.exhausted) || (i >= len(b.reports) && b.exhausted) {
+			return nil, false
+		}
+		return &Divergence{Report: i + 1, A: a.outcome(i), B: b.outcome(i)}, true
+	}
+
+	if a.exhausted || b.exhausted {
+		return nil, false
+	}
+	if (a.err == nil) != (b.err == nil) {
+		i := len(a.reports)
+		return &Divergence{Report: i + 1, A: a.outcome(i), B: b.outcome(i)}, true
+	}
+	return nil, true
+}
+
+// outcome returns the outcome of the i-th REPORT
+func (t *trace) outcome(i int) Outcome {
+	if i < len(t.reports) {
+		return Outcome{Step: t.reports[i].step, Output: t.reports[i].output}
+	}
+	return Outcome{Err: t.err}
+}
+
+// run runs the program from the start and records its REPORT output
+func run(prog *command.Program, cfg Config, start *table.Pose) (*trace, error) {
+	containers := map[command.Pos]bool{}
+	prog.Walk(func(n *command.Node) {
+		if n.Name == "CALL" || n.Name == "REPEAT" {
+			containers[n.Pos] = true
+		}
+	})
+
+	tr := &trace{}
+	out := &reportWriter{trace: tr}
+	tbl := table.New(cfg.SizeX, cfg.SizeY, table.WithReportOutput(out))
+	if start != nil {
+		if err := tbl.PlaceRobot(start.Pos, start.Facing); err != nil {
+			return nil, fmt.Errorf("invalid start %s: %w", start, err)
+		}
+	}
+
+	tr.err = command.Run(tbl, prog.Cmds, command.WithMaxSteps(cfg.MaxSteps), command.WithStepHook(func(pos command.Pos, t command.Table, err error) {
+		if !containers[pos] {
+			out.step++
+		}
+	}))
+	tr.exhausted = errors.Is(tr.err, command.ErrMaxSteps)
+	return tr, nil
+}
+
+// reportWriter records every REPORT output written by the table with the
+// step that produced it
+type reportWriter struct {
+	trace *trace
+	// step is the number of commands executed so far
+	step int
+}
+
+func (w *reportWriter) Write(p []byte) (int, error) {
+	w.trace.reports = append(w.trace.reports, report{
+		step:   w.step + 1,
+		output: strings.TrimSuffix(string(p), "\n"),
+	})
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package equiv_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/equiv"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		a           string
+		b           string
+		maxSteps    int
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "should prove programs with folded rotations equivalent",
+			a:    "PLACE 0,0,NORTH\nLEFT\nLEFT\nLEFT\nMOVE\nREPORT\n",
+			b:    "PLACE 0,0,NORTH\nRIGHT\nMOVE\nREPORT\n",
+		},
+		{
+			name: "should prove programs with loops equivalent",
+			a:    "MOVE\nMOVE\nMOVE\nREPORT\n",
+			b:    "REPEAT 3\n  MOVE\nEND\nREPORT\n",
+		},
+		{
+			name:     "should report divergence of programs that depend on the start",
+			a:        "MOVE\nMOVE\nMOVE\nREPORT\n",
+			b:        "MOVE 3\nREPORT\n",
+			expected: "programs diverge at REPORT #1 when starting at 1,0,WEST\n  a step 4: Robot position: (0, 0) facing: WEST\n  b step 2: Robot position: (1, 0) facing: WEST",
+		},
+		{
+			name:     "should report divergence of missing output",
+			a:        "PLACE 0,0,NORTH\nREPORT\nREPORT\n",
+			b:        "DEF R\n  REPORT\nEND\nPLACE 0,0,NORTH\nCALL R\n",
+			expected: "programs diverge at REPORT #2 when starting with the robot not placed\n  a step 3: Robot position: (0, 0) facing: NORTH\n  b no output",
+		},
+		{
+			name:     "should report divergence of failed run",
+			a:        "SET x = 0\nPLACE 0,0,NORTH\nREPORT\n",
+			b:        "SET x = 0\nPLACE 0,0,NORTH\nREPORT\nMOVE 1/x\n",
+			expected: "programs diverge at REPORT #2 when starting with the robot not placed\n  a no output\n  b failed: b.txt:4: division by zero",
+		},
+		{
+			name:     "should report divergence before the step budget is exhausted",
+			a:        "PLACE 0,0,NORTH\nREPORT\nREPEAT 1000000\n  LEFT\nEND\n",
+			b:        "PLACE 0,0,EAST\nREPORT\n",
+			maxSteps: 100,
+			expected: "programs diverge at REPORT #1 when starting with the robot not placed\n  a step 2: Robot position: (0, 0) facing: NORTH\n  b step 2: Robot position: (0, 0) facing: EAST",
+		},
+		{
+			name:        "should report comparison exhausting the step budget inconclusive",
+			a:           "PLACE 0,0,NORTH\nREPEAT 1000000\n  LEFT\nEND\nREPORT\n",
+			b:           "PLACE 0,0,NORTH\nREPORT\n",
+			maxSteps:    100,
+			expectedErr: "comparison is inconclusive: a run starting with the robot not placed exhausted the budget of 100 steps",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := map[string]string{"a.txt": tt.a, "b.txt": tt.b}
+			readFile := func(fileName string) ([]byte, error) {
+				src, ok := files[fileName]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", os.ErrNotExist, fileName)
+				}
+				return []byte(src), nil
+			}
+			a, err := command.Load("a.txt", command.WithReadFile(readFile))
+			require.NoError(t, err)
+			b, err := command.Load("b.txt", command.WithReadFile(readFile))
+			require.NoError(t, err)
+
+			d, err := equiv.Check(a, b, equiv.Config{SizeX: 5, SizeY: 5, MaxSteps: tt.maxSteps})
+			if tt.expectedErr != "" {
+				require.ErrorIs(t, err, equiv.ErrInconclusive)
+				require.EqualError(t, err, tt.expectedErr)
+				require.Nil(t, d)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected == "" {
+				require.Nil(t, d)
+				return
+			}
+			require.NotNil(t, d)
+			require.Equal(t, tt.expected, d.String())
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package equiv
+
+import "errors"
+
+var (
+	ErrInconclusive error = errors.New("comparison is inconclusive")
+)
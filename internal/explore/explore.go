@@ -0,0 +1,213 @@
+package explore
+
+import (
+	"fmt"
+	"strings"
+
+	"robot/internal/direction"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// Strategy decides where the robot goes next
+type Strategy int
+
+const (
+	// LeftHand walks straight until it hits a wall and then follows the wall
+	// keeping it on the left until it gets back to a pose it has been at
+	LeftHand Strategy = iota
+	// Frontier goes to the closest known free cell next to an unknown one
+	// until there is none
+	Frontier
+)
+
+// Strategies are all exploration strategies
+var Strategies = []Strategy{LeftHand, Frontier}
+
+// String returns the upper case name of Strategy
+func (s Strategy) String() string {
+	if s == Frontier {
+		return "FRONTIER"
+	}
+	return "LEFTHAND"
+}
+
+// ParseStrategy returns the strategy of the case insensitive name
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if s.String() == strings.ToUpper(name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid exploration strategy: '%s'", name)
+}
+
+// Result is the steps taken and the discovered map
+type Result struct {
+	Steps []plan.Step
+	Map   *Map
+	Moves int
+	// Turns is the number of quarter turns, turning around counts twice
+	Turns int
+}
+
+// String returns the statistics of Result
+func (r *Result) String() string {
+	free, walls := r.Map.Known()
+	sizeX, sizeY := r.Map.Size()
+	return fmt.Sprintf("discovered %d/%d cells (%d free, %d walls), %d moves, %d turns", free+walls, sizeX*sizeY, free, walls, r.Moves, r.Turns)
+}
+
+// Explore moves the robot around the world it does not know, the robot only
+// knows the size of the table and senses the cells next to it after every move
+func Explore(world plan.Grid, start table.Pose, strategy Strategy) (*Result, error) {
+	if err := plan.Validate(world, start.Pos); err != nil {
+		return nil, fmt.Errorf("invalid start %s: %w", start, err)
+	}
+
+	sizeX, sizeY := world.Size()
+	e := &explorer{
+		world: world,
+		pose:  start,
+		res: &Result{
+			Steps: []plan.Step{},
+			Map:   NewMap(sizeX, sizeY),
+		},
+	}
+	e.sense()
+
+	switch strategy {
+	case Frontier:
+		return e.res, e.frontier()
+	default:
+		e.leftHand()
+		return e.res, nil
+	}
+}
+
+type explorer struct {
+	// world is only looked at by sense
+	world plan.Grid
+	pose  table.Pose
+	res   *Result
+}
+
+// sense records the cell of the robot and its neighbours in the map
+func (e *explorer) sense() {
+	e.res.Map.set(e.pose.Pos, Free)
+	for _, d := range direction.All() {
+		next := d.Step(e.pose.Pos)
+		c := Free
+		if plan.Validate(e.world, next) != nil {
+			c = Wall
+		}
+		e.res.Map.set(next, c)
+	}
+}
+
+// apply records the step, updates the pose and senses after moves
+func (e *explorer) apply(s plan.Step) {
+	e.res.Steps = append(e.res.Steps, s)
+	switch s {
+	case plan.Move:
+		e.pose.Pos = e.pose.Facing.Step(e.pose.Pos)
+		e.res.Moves++
+		e.sense()
+	case plan.Left:
+		e.pose.Facing.RotateLeft()
+		e.res.Turns++
+	case plan.Right:
+		e.pose.Facing.RotateRight()
+		e.res.Turns++
+	case plan.UTurn:
+		e.pose.Facing = e.pose.Facing.Opposite()
+		e.res.Turns += 2
+	}
+}
+
+// free reports whether the cell in the direction from the robot is known to be
+// free
+func (e *explorer) free(d direction.Direction) bool {
+	return e.res.Map.Cell(d.Step(e.pose.Pos)) == Free
+}
+
+// leftHand follows the wall on the left of the robot, the robot goes around
+// in circles once it gets to a pose it has been at before
+func (e *explorer) leftHand() {
+	for e.free(e.pose.Facing) {
+		e.apply(plan.Move)
+	}
+	// the wall ahead ends up on the left
+	e.apply(plan.Right)
+
+	seen := map[table.Pose]bool{e.pose: true}
+	for {
+		left, right := e.pose.Facing, e.pose.Facing
+		left.RotateLeft()
+		right.RotateRight()
+
+		var turn []plan.Step
+		switch {
+		case e.free(left):
+			turn = []plan.Step{plan.Left}
+		case e.free(e.pose.Facing):
+		case e.free(right):
+			turn = []plan.Step{plan.Right}
+		case e.free(e.pose.Facing.Opposite()):
+			turn = []plan.Step{plan.UTurn}
+		default:
+			// the robot is walled in
+			return
+		}
+
+		for _, s := range turn {
+			e.apply(s)
+		}
+		e.apply(plan.Move)
+		if seen[e.pose] {
+			return
+		}
+		seen[e.pose] = true
+	}
+}
+
+// frontier goes to the closest frontier cell until there is none, the paths
+// are planned on the map so they only go through explored cells
+func (e *explorer) frontier() error {
+	for {
+		target, ok := e.closestFrontier()
+		if !ok {
+			return nil
+		}
+		steps, err := plan.Plan(e.res.Map, e.pose, plan.Goal{Pos: target}, plan.DefaultCost)
+		if err != nil {
+			return err
+		}
+		for _, s := range steps {
+			e.apply(s)
+		}
+	}
+}
+
+// closestFrontier returns the known free cell next to an unknown cell with the
+// shortest path from the robot through known free cells
+func (e *explorer) closestFrontier() (point.Point, bool) {
+	cells := []point.Point{e.pose.Pos}
+	seen := map[point.Point]bool{e.pose.Pos: true}
+	for i := 0; i < len(cells); i++ {
+		for _, d := range direction.All() {
+			next := d.Step(cells[i])
+			switch e.res.Map.Cell(next) {
+			case Unknown:
+				return cells[i], true
+			case Free:
+				if !seen[next] {
+					seen[next] = true
+					cells = append(cells, next)
+				}
+			}
+		}
+	}
+	return point.Point{}, false
+}
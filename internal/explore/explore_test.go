@@ -0,0 +1,117 @@
+package explore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/explore"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+func TestExplore(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		layout         string
+		start          string
+		strategy       explore.Strategy
+		expectedSource string
+		expectedMap    string
+		expectedStats  string
+		expectedErr    error
+	}{
+		{
+			name:           "should follow walls of empty table",
+			layout:         "...\n...\n...\n",
+			start:          "1,1,NORTH",
+			strategy:       explore.LeftHand,
+			expectedSource: "PLACE 1,1,NORTH\nMOVE\nRIGHT\nMOVE\nRIGHT\nMOVE 2\nRIGHT\nMOVE 2\nRIGHT\nMOVE 2\nRIGHT\nMOVE\n",
+			expectedMap:    "...\n...\n...\n",
+			expectedStats:  "discovered 9/9 cells (9 free, 0 walls), 9 moves, 5 turns",
+		},
+		{
+			name:           "should go to closest frontier on empty table",
+			layout:         "...\n...\n...\n",
+			start:          "1,1,NORTH",
+			strategy:       explore.Frontier,
+			expectedSource: "PLACE 1,1,NORTH\nRIGHT\nMOVE\nLEFT\nMOVE\nLEFT\nMOVE 2\nLEFT\nMOVE\n",
+			expectedMap:    "...\n...\n...\n",
+			expectedStats:  "discovered 9/9 cells (9 free, 0 walls), 5 moves, 4 turns",
+		},
+		{
+			name:          "should miss cells away from walls when following them",
+			layout:        ".....\n.....\n..#..\n.....\n.....\n",
+			start:         "0,0,NORTH",
+			strategy:      explore.LeftHand,
+			expectedMap:   ".....\n.....\n..?..\n.....\n.....\n",
+			expectedStats: "discovered 24/25 cells (24 free, 0 walls), 21 moves, 5 turns",
+		},
+		{
+			name:          "should discover every reachable cell of frontiers",
+			layout:        ".....\n.....\n..#..\n.....\n.....\n",
+			start:         "0,0,NORTH",
+			strategy:      explore.Frontier,
+			expectedMap:   ".....\n.....\n..#..\n.....\n.....\n",
+			expectedStats: "discovered 25/25 cells (24 free, 1 walls), 15 moves, 5 turns",
+		},
+		{
+			name:           "should not discover walled off cells",
+			layout:         ".#.\n",
+			start:          "0,0,EAST",
+			strategy:       explore.Frontier,
+			expectedSource: "PLACE 0,0,EAST\n",
+			expectedMap:    ".#?\n",
+			expectedStats:  "discovered 2/3 cells (1 free, 1 walls), 0 moves, 0 turns",
+		},
+		{
+			name:        "should fail to start on blocked cell",
+			layout:      ".#.\n",
+			start:       "1,0,EAST",
+			strategy:    explore.LeftHand,
+			expectedErr: table.ErrPositionBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			layout, err := table.ParseLayout([]byte(tt.layout))
+			require.NoError(t, err)
+			start, err := table.ParsePose(tt.start)
+			require.NoError(t, err)
+
+			res, err := explore.Explore(layout, start, tt.strategy)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectedSource != "" {
+				require.Equal(t, tt.expectedSource, string(plan.Source(start, res.Steps)))
+			}
+			require.Equal(t, tt.expectedMap, res.Map.String())
+			require.Equal(t, tt.expectedStats, res.String())
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+
+	m := explore.NewMap(3, 2)
+	require.Equal(t, "???\n???\n", m.String())
+	require.Equal(t, explore.Unknown, m.Cell(point.Point{X: 0, Y: 0}))
+	require.Equal(t, explore.Wall, m.Cell(point.Point{X: -1, Y: 0}))
+	require.Equal(t, explore.Wall, m.Cell(point.Point{X: 0, Y: 2}))
+	require.True(t, m.Blocked(point.Point{X: 0, Y: 0}))
+
+	free, walls := m.Known()
+	require.Zero(t, free)
+	require.Zero(t, walls)
+}
@@ -0,0 +1,98 @@
+package explore
+
+import (
+	"strings"
+
+	"robot/internal/point"
+)
+
+// Cell is what the robot knows about a cell of the table
+type Cell int
+
+const (
+	// Unknown cells were not sensed yet
+	Unknown Cell = iota
+	// Free cells can be entered
+	Free
+	// Wall cells are blocked
+	Wall
+)
+
+// Map is the memory of the robot, it knows the size of the table but only the
+// cells it has sensed
+type Map struct {
+	sizeX uint
+	sizeY uint
+	cells map[point.Point]Cell
+}
+
+// NewMap returns the map of the table with every cell unknown
+func NewMap(sizeX, sizeY uint) *Map {
+	return &Map{
+		sizeX: sizeX,
+		sizeY: sizeY,
+		cells: map[point.Point]Cell{},
+	}
+}
+
+// Size returns the table dimensions alongside X and Y axis
+func (m *Map) Size() (uint, uint) {
+	return m.sizeX, m.sizeY
+}
+
+// Cell returns what is known about the cell, cells outside of the table are
+// walls
+func (m *Map) Cell(pos point.Point) Cell {
+	if pos.X < 0 || pos.Y < 0 || uint(pos.X) >= m.sizeX || uint(pos.Y) >= m.sizeY {
+		return Wall
+	}
+	return m.cells[pos]
+}
+
+// Blocked reports whether the cell is not known to be free, so that plans on
+// the map only go through explored cells
+func (m *Map) Blocked(pos point.Point) bool {
+	return m.Cell(pos) != Free
+}
+
+// Known returns the number of free and wall cells of the table that were sensed
+func (m *Map) Known() (int, int) {
+	free, walls := 0, 0
+	for _, c := range m.cells {
+		switch c {
+		case Free:
+			free++
+		case Wall:
+			walls++
+		}
+	}
+	return free, walls
+}
+
+// String returns rows of '.' free, '#' wall and '?' unknown cells, the first
+// row is the one furthest to the north like in table layouts
+func (m *Map) String() string {
+	var sb strings.Builder
+	for y := int(m.sizeY) - 1; y >= 0; y-- {
+		for x := 0; x < int(m.sizeX); x++ {
+			switch m.cells[point.Point{X: x, Y: y}] {
+			case Free:
+				sb.WriteRune('.')
+			case Wall:
+				sb.WriteRune('#')
+			default:
+				sb.WriteRune('?')
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// set records the sensed cell, cells outside of the table are not stored
+func (m *Map) set(pos point.Point, c Cell) {
+	if pos.X < 0 || pos.Y < 0 || uint(pos.X) >= m.sizeX || uint(pos.Y) >= m.sizeY {
+		return
+	}
+	m.cells[pos] = c
+}
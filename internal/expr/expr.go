@@ -0,0 +1,291 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrDivisionByZero error = errors.New("division by zero")
+	ErrUndefined      error = errors.New("undefined identifier")
+)
+
+// Lookup resolves the value of an identifier used in an expression
+type Lookup func(name string) (int, error)
+
+// Expr is an integer arithmetic expression
+type Expr interface {
+	// Eval evaluates the expression, identifiers are resolved with lookup
+	Eval(lookup Lookup) (int, error)
+	// String returns the canonical representation of the expression
+	String() string
+}
+
+// Num is an integer literal
+type Num int
+
+// Ident is a reference to a variable or a built-in value
+type Ident string
+
+// Unary is an unary minus applied to an expression
+type Unary struct {
+	X Expr
+}
+
+// Binary is an arithmetic operation on two expressions
+type Binary struct {
+	Op   byte
+	X, Y Expr
+}
+
+func (n Num) Eval(Lookup) (int, error) {
+	return int(n), nil
+}
+
+func (n Num) String() string {
+	return strconv.Itoa(int(n))
+}
+
+func (i Ident) Eval(lookup Lookup) (int, error) {
+	if lookup == nil {
+		return 0, fmt.Errorf("%w: %s", ErrUndefined, string(i))
+	}
+	return lookup(string(i))
+}
+
+func (i Ident) String() string {
+	return string(i)
+}
+
+func (u Unary) Eval(lookup Lookup) (int, error) {
+	x, err := u.X.Eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	return -x, nil
+}
+
+func (u Unary) String() string {
+	if _, ok := u.X.(Binary); ok {
+		return "-(" + u.X.String() + ")"
+	}
+	return "-" + u.X.String()
+}
+
+func (b Binary) Eval(lookup Lookup) (int, error) {
+	x, err := b.X.Eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	y, err := b.Y.Eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.Op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	case '/', '%':
+		if y == 0 {
+			return 0, ErrDivisionByZero
+		}
+		if b.Op == '/' {
+			return x / y, nil
+		}
+		return x % y, nil
+	default:
+		return 0, fmt.Errorf("unknown operator '%c'", b.Op)
+	}
+}
+
+func (b Binary) String() string {
+	x, y := b.X.String(), b.Y.String()
+	if precedence(b.X) < precedence(b) {
+		x = "(" + x + ")"
+	}
+	// operators are left associative so the right operand needs parentheses
+	// even when it binds equally strong
+	if precedence(b.Y) <= precedence(b) {
+		y = "(" + y + ")"
+	}
+	return x + string(b.Op) + y
+}
+
+// precedence returns binding strength of the expression's top level operator
+func precedence(e Expr) int {
+	b, ok := e.(Binary)
+	if !ok {
+		return 3
+	}
+	if b.Op == '+' || b.Op == '-' {
+		return 1
+	}
+	return 2
+}
+
+// Parse parses an integer arithmetic expression, supported are integer
+// literals, identifiers, parentheses, unary minus and the + - * / % operators
+func Parse(src string) (Expr, error) {
+	p := &parser{src: src}
+	p.next()
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("unexpected '%s' in expression '%s'", p.tok, src)
+	}
+	return e, nil
+}
+
+// IsIdent reports whether s is a valid identifier name
+func IsIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+type parser struct {
+	src string
+	off int
+	tok string
+}
+
+// next advances to the following token, tok is empty at the end of input
+func (p *parser) next() {
+	for p.off < len(p.src) && unicode.IsSpace(rune(p.src[p.off])) {
+		p.off++
+	}
+	start := p.off
+	if p.off >= len(p.src) {
+		p.tok = ""
+		return
+	}
+
+	c := rune(p.src[p.off])
+	switch {
+	case unicode.IsDigit(c):
+		for p.off < len(p.src) && unicode.IsDigit(rune(p.src[p.off])) {
+			p.off++
+		}
+	case c == '_' || unicode.IsLetter(c):
+		for p.off < len(p.src) && (p.src[p.off] == '_' || unicode.IsLetter(rune(p.src[p.off])) || unicode.IsDigit(rune(p.src[p.off]))) {
+			p.off++
+		}
+	default:
+		p.off++
+	}
+	p.tok = p.src[start:p.off]
+}
+
+func (p *parser) parseSum() (Expr, error) {
+	x, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "+" || p.tok == "-" {
+		op := p.tok[0]
+		p.next()
+		y, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: op, X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseProduct() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "*" || p.tok == "/" || p.tok == "%" {
+		op := p.tok[0]
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{Op: op, X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.tok == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := x.(Num); ok {
+			return -n, nil
+		}
+		return Unary{X: x}, nil
+	}
+	if p.tok == "+" {
+		p.next()
+		return p.parseUnary()
+	}
+	return p.parseOperand()
+}
+
+func (p *parser) parseOperand() (Expr, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression '%s'", p.src)
+	case tok == "(":
+		p.next()
+		x, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing ')' in expression '%s'", p.src)
+		}
+		p.next()
+		return x, nil
+	case unicode.IsDigit(rune(tok[0])):
+		n, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' in expression '%s'", tok, p.src)
+		}
+		p.next()
+		return Num(n), nil
+	case IsIdent(tok):
+		p.next()
+		return Ident(strings.ToUpper(tok)), nil
+	default:
+		return nil, fmt.Errorf("unexpected '%s' in expression '%s'", tok, p.src)
+	}
+}
+
+// Idents returns names of all identifiers referenced by the expression
+func Idents(e Expr) []string {
+	switch x := e.(type) {
+	case Ident:
+		return []string{string(x)}
+	case Unary:
+		return Idents(x.X)
+	case Binary:
+		return append(Idents(x.X), Idents(x.Y)...)
+	default:
+		return nil
+	}
+}
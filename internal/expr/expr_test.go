@@ -0,0 +1,144 @@
+package expr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/expr"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	lookup := func(name string) (int, error) {
+		switch name {
+		case "W":
+			return 5, nil
+		case "H":
+			return 4, nil
+		}
+		return 0, fmt.Errorf("%w: %s", expr.ErrUndefined, name)
+	}
+
+	tests := [...]struct {
+		name        string
+		src         string
+		expected    int
+		expectedErr error
+	}{
+		{
+			name:     "should evaluate integer literal",
+			src:      "42",
+			expected: 42,
+		},
+		{
+			name:     "should evaluate operators by precedence",
+			src:      "1 + 2 * 3 - 8 / 4",
+			expected: 5,
+		},
+		{
+			name:     "should evaluate parentheses and unary minus",
+			src:      "-(1 + 2) * -2 % 4",
+			expected: 2,
+		},
+		{
+			name:     "should evaluate lower case identifiers",
+			src:      "w - 1 + h / 2",
+			expected: 6,
+		},
+		{
+			name:        "should fail to evaluate undefined identifier",
+			src:         "x + 1",
+			expectedErr: expr.ErrUndefined,
+		},
+		{
+			name:        "should fail to divide by zero",
+			src:         "w / (h - 4)",
+			expectedErr: expr.ErrDivisionByZero,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := expr.Parse(tt.src)
+			require.NoError(t, err)
+
+			actual, err := e.Eval(lookup)
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		src       string
+		expected  string
+		shouldErr bool
+	}{
+		{
+			name:     "should print canonical form of expression",
+			src:      " w -1 ",
+			expected: "W-1",
+		},
+		{
+			name:     "should keep required parentheses",
+			src:      "(a - (b - c)) * (d + 1)",
+			expected: "(A-(B-C))*(D+1)",
+		},
+		{
+			name:     "should drop redundant parentheses",
+			src:      "((a * b)) + (c / 2)",
+			expected: "A*B+C/2",
+		},
+		{
+			name:     "should fold negative literal",
+			src:      "-(3)",
+			expected: "-3",
+		},
+		{
+			name:      "should fail to parse trailing operator",
+			src:       "1 +",
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to parse unbalanced parentheses",
+			src:       "(1 + 2",
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to parse two operands in a row",
+			src:       "w h",
+			shouldErr: true,
+		},
+		{
+			name:      "should fail to parse empty expression",
+			src:       "  ",
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := expr.Parse(tt.src)
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, e.String())
+		})
+	}
+}
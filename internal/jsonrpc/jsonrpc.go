@@ -0,0 +1,395 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version is the protocol version every message carries
+const Version = "2.0"
+
+// MaxMessageSize limits the size of a single frame read by codecs, larger
+// frames fail with ErrMessageTooLarge
+const MaxMessageSize = 8 << 20
+
+// Error codes defined by the JSON-RPC 2.0 specification
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+var (
+	// ErrStop can be returned by a handler to stop serving once the response
+	// to the current message was written
+	ErrStop error = errors.New("stop serving")
+	// ErrMessageTooLarge is returned by codecs reading a frame larger than
+	// MaxMessageSize
+	ErrMessageTooLarge error = errors.New("message too large")
+)
+
+// Error is the error object of a response
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// Message is a request, a notification or a response, requests and responses
+// carry an ID while notifications don't
+type Message struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Codec reads and writes framed messages, a frame holds a single message or
+// a batch of them
+type Codec interface {
+	// ReadFrame returns the body of the next frame
+	ReadFrame() ([]byte, error)
+	// WriteFrame writes the body as a single frame
+	WriteFrame(body []byte) error
+	// ReadMessage reads the next frame holding a single message
+	ReadMessage() (*Message, error)
+	// WriteMessage writes the message as a single frame
+	WriteMessage(msg *Message) error
+}
+
+// Decode decodes a single message, it fails with a parse error for invalid
+// JSON and with an invalid request error for JSON that is not a message
+func Decode(body []byte) (*Message, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, &Error{Code: CodeParseError, Message: err.Error()}
+	}
+	if raw[0] != '{' {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "message is not an object"}
+	}
+
+	msg := &Message{}
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return nil, &Error{Code: CodeInvalidRequest, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+// headerCodec frames messages with the Content-Length header used by the
+// Language Server Protocol
+type headerCodec struct {
+	r *textproto.Reader
+	w io.Writer
+}
+
+// NewHeaderCodec returns codec framing messages with Content-Length headers
+func NewHeaderCodec(r io.Reader, w io.Writer) Codec {
+	return &headerCodec{
+		r: textproto.NewReader(bufio.NewReader(r)),
+		w: w,
+	}
+}
+
+func (c *headerCodec) ReadFrame() ([]byte, error) {
+	header, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed reading message header: %w", err)
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header: '%s'", header.Get("Content-Length"))
+	}
+	if length > MaxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrMessageTooLarge, length, MaxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r.R, body); err != nil {
+		return nil, fmt.Errorf("failed reading message body: %w", err)
+	}
+	return body, nil
+}
+
+func (c *headerCodec) WriteFrame(body []byte) error {
+	_, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (c *headerCodec) ReadMessage() (*Message, error) {
+	return readMessage(c)
+}
+
+func (c *headerCodec) WriteMessage(msg *Message) error {
+	return writeMessage(c, msg)
+}
+
+// lineCodec frames messages as single lines of JSON
+type lineCodec struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// NewLineCodec returns codec framing messages as lines of JSON, blank lines
+// are skipped
+func NewLineCodec(r io.Reader, w io.Writer) Codec {
+	return &lineCodec{
+		r: bufio.NewReader(r),
+		w: w,
+	}
+}
+
+func (c *lineCodec) ReadFrame() ([]byte, error) {
+	for {
+		line, err := c.readLine()
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed reading message: %w", err)
+		}
+		return line, nil
+	}
+}
+
+// readLine reads up to the end of the line without buffering more than
+// MaxMessageSize bytes
+func (c *lineCodec) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := c.r.ReadSlice('\n')
+		if len(line)+len(chunk) > MaxMessageSize {
+			return nil, fmt.Errorf("%w: the limit is %d bytes", ErrMessageTooLarge, MaxMessageSize)
+		}
+		line = append(line, chunk...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, err
+		}
+	}
+}
+
+func (c *lineCodec) WriteFrame(body []byte) error {
+	_, err := fmt.Fprintf(c.w, "%s\n", body)
+	return err
+}
+
+func (c *lineCodec) ReadMessage() (*Message, error) {
+	return readMessage(c)
+}
+
+func (c *lineCodec) WriteMessage(msg *Message) error {
+	return writeMessage(c, msg)
+}
+
+func readMessage(c Codec) (*Message, error) {
+	body, err := c.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return Decode(body)
+}
+
+func writeMessage(c Codec, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.WriteFrame(body)
+}
+
+// Handler handles requests and notifications, results of notifications are
+// discarded
+type Handler func(method string, params json.RawMessage) (interface{}, error)
+
+// Conn serves requests read from the codec and allows sending notifications
+type Conn struct {
+	codec     Codec
+	errOutput io.Writer
+	mu        sync.Mutex
+}
+
+// Option is an option that can be passed to `NewConn`
+type Option func(*Conn)
+
+// WithErrorOutput provides an option to specify custom output for failures of
+// notification handlers, they can not be sent back. Failures are written to
+// stderr by default
+func WithErrorOutput(w io.Writer) Option {
+	return func(c *Conn) {
+		c.errOutput = w
+	}
+}
+
+func NewConn(codec Codec, opts ...Option) *Conn {
+	c := &Conn{
+		codec:     codec,
+		errOutput: os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Notify sends a notification to the other side of the connection
+func (c *Conn) Notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&Message{Version: Version, Method: method, Params: raw})
+}
+
+func (c *Conn) write(msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.codec.WriteMessage(msg)
+}
+
+// Serve reads frames until the end of input or until the handler returns
+// ErrStop, handler errors are sent back to the caller of the request and
+// written to the error output for notifications, notifications of unknown
+// methods are ignored silently. Messages
+// of a batch are handled in order and their responses are sent back as a
+// single batch once all of them were handled
+func (c *Conn) Serve(h Handler) error {
+	for {
+		body, err := c.codec.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var stop bool
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			stop, err = c.serveBatch(trimmed, h)
+		} else {
+			var resp *Message
+			if resp, stop = c.serveMessage(body, h); resp != nil {
+				err = c.write(resp)
+			}
+		}
+		if err != nil || stop {
+			return err
+		}
+	}
+}
+
+// serveBatch handles messages of the batch, an empty batch is an invalid
+// request on its own
+func (c *Conn) serveBatch(body []byte, h Handler) (bool, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return false, c.write(failure(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+	}
+	if len(raws) == 0 {
+		return false, c.write(failure(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+	}
+
+	resps := []*Message{}
+	stop := false
+	for _, raw := range raws {
+		resp, stopped := c.serveMessage(raw, h)
+		if resp != nil {
+			resps = append(resps, resp)
+		}
+		stop = stop || stopped
+	}
+	if len(resps) == 0 {
+		return stop, nil
+	}
+
+	out, err := json.Marshal(resps)
+	if err != nil {
+		return stop, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return stop, c.codec.WriteFrame(out)
+}
+
+// serveMessage decodes and handles a single message, it returns the response
+// to send which is nil for notifications
+func (c *Conn) serveMessage(body []byte, h Handler) (*Message, bool) {
+	msg, err := Decode(body)
+	if err != nil {
+		return failure(nil, err.(*Error)), false
+	}
+	return c.handle(msg, h)
+}
+
+// handle dispatches a single message, responses to requests sent by this side
+// of the connection are ignored
+func (c *Conn) handle(msg *Message, h Handler) (*Message, bool) {
+	if msg.Version != Version {
+		return failure(msg.ID, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("unsupported version: '%s'", msg.Version)}), false
+	}
+	if msg.Method == "" {
+		if msg.ID == nil {
+			return failure(nil, &Error{Code: CodeInvalidRequest, Message: "missing method"}), false
+		}
+		return nil, false
+	}
+
+	result, err := h(msg.Method, msg.Params)
+	stop := errors.Is(err, ErrStop)
+	if stop {
+		err = nil
+	}
+	if msg.ID == nil {
+		var rpcErr *Error
+		if err != nil && !(errors.As(err, &rpcErr) && rpcErr.Code == CodeMethodNotFound) {
+			fmt.Fprintf(c.errOutput, "notification %s failed: %s\n", msg.Method, err)
+		}
+		return nil, stop
+	}
+
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		return failure(msg.ID, rpcErr), stop
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return failure(msg.ID, &Error{Code: CodeInternalError, Message: err.Error()}), stop
+	}
+	return &Message{Version: Version, ID: msg.ID, Result: raw}, stop
+}
+
+// failure returns the error response to the request, the id is null when it
+// could not be determined
+func failure(id json.RawMessage, err *Error) *Message {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Message{Version: Version, ID: id, Error: err}
+}
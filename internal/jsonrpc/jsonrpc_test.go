@@ -0,0 +1,179 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/jsonrpc"
+)
+
+func frame(body string) string {
+	return "Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	in := frame(`{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`) +
+		frame(`{"jsonrpc":"2.0","method":"notify"}`) +
+		frame(`{"jsonrpc":"2.0","id":2,"method":"fail"}`) +
+		frame(`{"jsonrpc":"2.0","id":"3","method":"unknown"}`) +
+		frame(`{"jsonrpc":"2.0","id":4,"method":"stop"}`) +
+		frame(`{"jsonrpc":"2.0","id":5,"method":"echo"}`)
+	out := &bytes.Buffer{}
+
+	notified := 0
+	conn := jsonrpc.NewConn(jsonrpc.NewHeaderCodec(strings.NewReader(in), out))
+	err := conn.Serve(func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case "echo":
+			return params, nil
+		case "notify":
+			notified++
+			return "ignored", nil
+		case "fail":
+			return nil, errors.New("failed")
+		case "stop":
+			return nil, jsonrpc.ErrStop
+		}
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "method not found"}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, notified)
+
+	codec := jsonrpc.NewHeaderCodec(out, nil)
+	expected := []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"text":"hi"}}`,
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"failed"}}`,
+		`{"jsonrpc":"2.0","id":"3","error":{"code":-32601,"message":"method not found"}}`,
+		`{"jsonrpc":"2.0","id":4,"result":null}`,
+	}
+	for _, exp := range expected {
+		msg, err := codec.ReadMessage()
+		require.NoError(t, err)
+		actual, err := json.Marshal(msg)
+		require.NoError(t, err)
+		require.JSONEq(t, exp, string(actual))
+	}
+	_, err = codec.ReadMessage()
+	require.Error(t, err)
+}
+
+func TestServeNotificationFailure(t *testing.T) {
+	t.Parallel()
+
+	in := `{"jsonrpc":"2.0","method":"fail"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"unknown"}` + "\n" +
+		`[{"jsonrpc":"2.0","method":"fail"}]` + "\n"
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+
+	conn := jsonrpc.NewConn(jsonrpc.NewLineCodec(strings.NewReader(in), out), jsonrpc.WithErrorOutput(errOut))
+	err := conn.Serve(func(method string, params json.RawMessage) (interface{}, error) {
+		if method == "fail" {
+			return nil, errors.New("failed")
+		}
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "method not found"}
+	})
+	require.NoError(t, err)
+	require.Empty(t, out.String())
+	require.Equal(t, "notification fail failed: failed\nnotification fail failed: failed\n", errOut.String())
+}
+
+func TestLineCodec(t *testing.T) {
+	t.Parallel()
+
+	in := `{"jsonrpc":"2.0","id":1,"method":"echo","params":[1,2]}` + "\n\n" +
+		`{"jsonrpc":"2.0","id":2,` + "\n" +
+		`{"jsonrpc":"2.0","id":3,"method":"echo","params":"last"}`
+	out := &bytes.Buffer{}
+
+	conn := jsonrpc.NewConn(jsonrpc.NewLineCodec(strings.NewReader(in), out))
+	err := conn.Serve(func(method string, params json.RawMessage) (interface{}, error) {
+		return params, nil
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":[1,2]}`, lines[0])
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`, lines[1])
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":3,"result":"last"}`, lines[2])
+}
+
+func TestServeInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		in       string
+		expected []string
+	}{
+		{
+			name:     "old version",
+			in:       `{"jsonrpc":"1.0","id":1,"method":"echo"}`,
+			expected: []string{`{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"unsupported version: '1.0'"}}`},
+		},
+		{
+			name:     "not an object",
+			in:       `42`,
+			expected: []string{`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"message is not an object"}}`},
+		},
+		{
+			name:     "empty batch",
+			in:       `[]`,
+			expected: []string{`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`},
+		},
+		{
+			name: "batch",
+			in:   `[{"jsonrpc":"2.0","id":1,"method":"echo","params":"a"},{"jsonrpc":"2.0","method":"echo"},1,{"jsonrpc":"2.0","id":2,"method":"echo","params":"b"}]`,
+			expected: []string{`[
+				{"jsonrpc":"2.0","id":1,"result":"a"},
+				{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"message is not an object"}},
+				{"jsonrpc":"2.0","id":2,"result":"b"}
+			]`},
+		},
+		{
+			name: "batch of notifications",
+			in:   `[{"jsonrpc":"2.0","method":"echo"},{"jsonrpc":"2.0","method":"echo"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			conn := jsonrpc.NewConn(jsonrpc.NewLineCodec(strings.NewReader(tt.in), out))
+			err := conn.Serve(func(method string, params json.RawMessage) (interface{}, error) {
+				return params, nil
+			})
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(tt.expected) == 0 {
+				require.Empty(t, out.String())
+				return
+			}
+			require.Len(t, lines, len(tt.expected))
+			for i, exp := range tt.expected {
+				require.JSONEq(t, exp, lines[i])
+			}
+		})
+	}
+}
+
+func TestHeaderCodecTooLarge(t *testing.T) {
+	t.Parallel()
+
+	in := "Content-Length: " + strconv.Itoa(jsonrpc.MaxMessageSize+1) + "\r\n\r\n"
+	codec := jsonrpc.NewHeaderCodec(strings.NewReader(in), nil)
+	_, err := codec.ReadMessage()
+	require.ErrorIs(t, err, jsonrpc.ErrMessageTooLarge)
+}
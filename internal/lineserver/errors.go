@@ -0,0 +1,10 @@
+package lineserver
+
+import "errors"
+
+var (
+	ErrServerClosed error = errors.New("server closed")
+	ErrTooManyConns error = errors.New("too many connections")
+	ErrIdleTimeout  error = errors.New("idle timeout")
+	ErrInternal     error = errors.New("internal error")
+)
@@ -0,0 +1,250 @@
+package lineserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"robot/internal/command"
+	"robot/internal/session"
+	"robot/internal/table"
+)
+
+const (
+	// DefaultMaxSteps is the number of commands a line may execute when
+	// `Config.MaxSteps` is not chosen otherwise, e.g. by the listen command
+	DefaultMaxSteps = 1000000
+	// DefaultTimeout is the time a line may execute commands for when
+	// `Config.Timeout` is not chosen otherwise
+	DefaultTimeout = 10 * time.Second
+)
+
+// Config defines the tables connections drive and the connection limits
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// Shared makes every connection drive the same table, otherwise every
+	// connection gets a table of its own
+	Shared bool
+	// IdleTimeout closes connections that sent no line for the duration, zero
+	// disables it
+	IdleTimeout time.Duration
+	// MaxConns limits the number of connections served at once, zero disables
+	// the limit
+	MaxConns int
+	// MaxSteps limits the number of commands a single line may execute, zero
+	// disables the limit
+	MaxSteps int
+	// Timeout limits the time a single line may execute commands for
+	// including the time it waits for a shared table, zero disables it
+	Timeout time.Duration
+}
+
+// Server executes commands received as lines over TCP. Every line is a single
+// command in the command file syntax answered by OK, ERR <reason> or the
+// REPORT line. Blank lines and comments are not answered
+type Server struct {
+	cfg      Config
+	registry *command.Registry
+	shared   *session.Session
+	// ctx is cancelled once the server is closed to stop running commands
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Server)
+
+// WithRegistry provides an option to use custom command registry
+func WithRegistry(r *command.Registry) Option {
+	return func(s *Server) {
+		s.registry = r
+	}
+}
+
+func New(cfg Config, opts ...Option) *Server {
+	s := &Server{
+		cfg:      cfg,
+		registry: command.DefaultRegistry,
+		conns:    map[net.Conn]bool{},
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if cfg.Shared {
+		s.shared = s.newTable()
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Server) newTable() *session.Session {
+	return session.New("", table.Layout{SizeX: s.cfg.SizeX, SizeY: s.cfg.SizeY})
+}
+
+// Serve accepts connections on the listener until it fails or the server is
+// closed, it returns nil once the server was closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		if !s.track(conn) {
+			fmt.Fprintf(conn, "ERR %s\n", ErrTooManyConns)
+			conn.Close()
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// track registers the connection unless the server is full or closed
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || (s.cfg.MaxConns > 0 && len(s.conns) >= s.cfg.MaxConns) {
+		return false
+	}
+	s.conns[conn] = true
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	s.wg.Done()
+}
+
+// Close stops accepting connections, closes the open ones and waits until
+// they are done
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.cancel()
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// serveConn answers lines of the connection until it is closed, it times out
+// or HALT is received. Variables assigned by lines are kept for the lifetime of
+// the connection. A panicking command closes only its own connection
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	sess := s.shared
+	if sess == nil {
+		sess = s.newTable()
+	}
+	scope := s.registry.NewScope()
+
+	r := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(w, "ERR %s: %v\n", ErrInternal, p)
+			w.Flush()
+		}
+	}()
+	for {
+		if s.cfg.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.cfg.IdleTimeout))
+		}
+		if !r.Scan() {
+			var netErr net.Error
+			switch err := r.Err(); {
+			case errors.As(err, &netErr) && netErr.Timeout():
+				fmt.Fprintf(w, "ERR %s\n", ErrIdleTimeout)
+			case errors.Is(err, bufio.ErrTooLong):
+				fmt.Fprintf(w, "ERR %s\n", err)
+			}
+			w.Flush()
+			return
+		}
+
+		line := command.StripComment(r.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		reply, halt := s.exec(sess, scope, line)
+		io.WriteString(w, reply)
+		if err := w.Flush(); err != nil || halt {
+			return
+		}
+	}
+}
+
+// exec executes the command of the line and returns the reply, halt is set
+// when the connection should be closed after the reply
+func (s *Server) exec(sess *session.Session, scope *command.Scope, line string) (reply string, halt bool) {
+	cmd, err := scope.Parse(line)
+	if err != nil {
+		return fmt.Sprintf("ERR %s\n", err), false
+	}
+	// HALT stops the run without an error, it is recorded to close the
+	// connection
+	checked := func(t command.Table, env *command.Env) error {
+		err := cmd(t, env)
+		halt = errors.Is(err, command.ErrHalt)
+		return err
+	}
+
+	ctx := s.ctx
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	res, err := sess.Exec(ctx, checked, command.WithMaxSteps(s.cfg.MaxSteps), command.WithScope(scope))
+	switch {
+	case err != nil:
+		return fmt.Sprintf("ERR %s\n", err), false
+	case res.Output != "":
+		return res.Output, halt
+	default:
+		return "OK\n", halt
+	}
+}
@@ -0,0 +1,185 @@
+package lineserver_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/lineserver"
+)
+
+// start serves the config on a random local port and returns its address
+func start(t *testing.T, cfg lineserver.Config, opts ...lineserver.Option) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := lineserver.New(cfg, opts...)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, srv.Close())
+		require.NoError(t, <-done)
+	})
+	return l.Addr().String()
+}
+
+// client is a connection reading replies line by line
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *client {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// send writes the lines and returns the given number of reply lines
+func (c *client) send(lines string, replies int) []string {
+	_, err := io.WriteString(c.conn, lines)
+	require.NoError(c.t, err)
+
+	actual := []string{}
+	for i := 0; i < replies; i++ {
+		line, err := c.r.ReadString('\n')
+		require.NoError(c.t, err)
+		actual = append(actual, strings.TrimSuffix(line, "\n"))
+	}
+	return actual
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		lines    string
+		expected []string
+	}{
+		{
+			name:  "should reply to every command",
+			lines: "PLACE 1,2,EAST\nMOVE\nleft\nREPORT\n",
+			expected: []string{
+				"OK",
+				"OK",
+				"OK",
+				"Robot position: (2, 2) facing: NORTH",
+			},
+		},
+		{
+			name:  "should reply with refusals and invalid commands",
+			lines: "MOVE\nPLACE 5,5,NORTH\nJUMP\nMOVE 1,2,3\n",
+			expected: []string{
+				"ERR uninitialized placement",
+				"ERR ending position out of bounds",
+				"ERR invalid command detected: 'JUMP'",
+				"ERR MOVE command requires 0 to 2 parameters, but 3 were detected",
+			},
+		},
+		{
+			name:  "should keep variables between lines",
+			lines: "SET x = 1\nSET y = x + 2\nPLACE x,y,NORTH\nPLACE z,0,NORTH\nREPORT\n",
+			expected: []string{
+				"OK",
+				"OK",
+				"OK",
+				"ERR x parameter is not a valid expression(Z): undefined identifier: Z",
+				"Robot position: (1, 3) facing: NORTH",
+			},
+		},
+		{
+			name:  "should skip blank lines and comments",
+			lines: "\n# placing the robot\nPLACE 0,0,NORTH # at the origin\n\r\nREPORT\n",
+			expected: []string{
+				"OK",
+				"Robot position: (0, 0) facing: NORTH",
+			},
+		},
+	}
+
+	addr := start(t, lineserver.Config{SizeX: 5, SizeY: 5})
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := dial(t, addr)
+			require.Equal(t, tt.expected, c.send(tt.lines, len(tt.expected)))
+		})
+	}
+}
+
+func TestServerTables(t *testing.T) {
+	t.Parallel()
+
+	private := start(t, lineserver.Config{SizeX: 5, SizeY: 5})
+	a, b := dial(t, private), dial(t, private)
+	require.Equal(t, []string{"OK"}, a.send("PLACE 1,1,WEST\n", 1))
+	require.Equal(t, []string{"ERR uninitialized placement"}, b.send("REPORT\n", 1))
+
+	shared := start(t, lineserver.Config{SizeX: 5, SizeY: 5, Shared: true})
+	a, b = dial(t, shared), dial(t, shared)
+	require.Equal(t, []string{"OK"}, a.send("PLACE 1,1,WEST\n", 1))
+	require.Equal(t, []string{"Robot position: (1, 1) facing: WEST"}, b.send("REPORT\n", 1))
+}
+
+func TestServerLimits(t *testing.T) {
+	t.Parallel()
+
+	addr := start(t, lineserver.Config{SizeX: 5, SizeY: 5, IdleTimeout: 50 * time.Millisecond, MaxConns: 1})
+
+	c := dial(t, addr)
+	require.Equal(t, []string{"OK"}, c.send("PLACE 0,0,NORTH\n", 1))
+	require.Equal(t, []string{"ERR too many connections"}, dial(t, addr).send("", 1))
+
+	require.Equal(t, []string{"ERR idle timeout"}, c.send("", 1))
+	_, err := c.r.ReadString('\n')
+	require.ErrorIs(t, err, io.EOF)
+
+	// the connection is released once it timed out
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return false
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HALT\n")
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		return line == "OK\n"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServerPanic(t *testing.T) {
+	t.Parallel()
+
+	registry := command.NewRegistry()
+	require.NoError(t, registry.Register(command.Spec{
+		Name: "CRASH",
+		Help: "panics",
+		Exec: func(t command.Table, env *command.Env, args []command.Value) error {
+			panic("boom")
+		},
+	}))
+	addr := start(t, lineserver.Config{SizeX: 5, SizeY: 5, Shared: true}, lineserver.WithRegistry(registry))
+
+	c := dial(t, addr)
+	require.Equal(t, []string{"OK", "ERR internal error: boom"}, c.send("PLACE 0,0,NORTH\nCRASH\n", 2))
+	_, err := c.r.ReadString('\n')
+	require.ErrorIs(t, err, io.EOF)
+
+	// the shared table is released by the panicking connection
+	require.Equal(t, []string{"Robot position: (0, 0) facing: NORTH"}, dial(t, addr).send("REPORT\n", 1))
+}
@@ -0,0 +1,384 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"robot/internal/command"
+	"robot/internal/expr"
+)
+
+// Severity tells how likely a finding is a mistake
+type Severity int
+
+const (
+	// Warning marks statements that have no effect
+	Warning Severity = iota
+	// Error marks statements that can never do what they were written for
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Rule is a single check performed by the linter
+type Rule struct {
+	ID       string
+	Severity Severity
+	Help     string
+}
+
+// Rules are all checks performed by the linter
+var Rules = []Rule{
+	{ID: "L001", Severity: Warning, Help: "command is executed before the first PLACE and is always ignored"},
+	{ID: "L002", Severity: Error, Help: "PLACE position is outside of the table and is always ignored"},
+	{ID: "L003", Severity: Warning, Help: "consecutive rotations cancel each other out"},
+	{ID: "L004", Severity: Warning, Help: "four consecutive identical rotations turn the robot back to its facing"},
+	{ID: "L005", Severity: Warning, Help: "REPORT is executed before the first PLACE and can never produce output"},
+	{ID: "L006", Severity: Warning, Help: "statement is unreachable as it follows HALT"},
+}
+
+// Finding is a rule violated by a statement
+type Finding struct {
+	Pos      command.Pos
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+// String returns a string representation of Finding in the
+// 'file:line: rule severity: message' form
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", f.Pos, f.Rule, f.Severity, f.Message)
+}
+
+// Config holds the table size the program is checked against
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// Registry is the one the program was loaded with, it tells commands that
+	// need a placed robot. command.DefaultRegistry is used when nil
+	Registry *command.Registry
+}
+
+// Lint checks the loaded program without running it and returns findings
+// sorted by position. Rules can be disabled per file with a comment line
+// '# lint:disable' followed by comma separated rule IDs, without IDs all rules
+// are disabled
+func Lint(prog *command.Program, cfg Config) []Finding {
+	if cfg.Registry == nil {
+		cfg.Registry = command.DefaultRegistry
+	}
+	l := &linter{
+		cfg:   cfg,
+		prog:  prog,
+		halts: map[string]bool{},
+		seen:  map[string]bool{},
+	}
+
+	l.checkPlacement()
+	l.checkList(prog.File.Nodes)
+	prog.Walk(func(n *command.Node) {
+		switch {
+		case n.Name == "DEF" || n.Name == "REPEAT":
+			l.checkList(n.Body)
+		case n.Include != nil:
+			l.checkList(n.Include.Nodes)
+		}
+	})
+
+	findings := suppress(prog, l.findings)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Pos.File != findings[j].Pos.File {
+			return findings[i].Pos.File < findings[j].Pos.File
+		}
+		return findings[i].Pos.Line < findings[j].Pos.Line
+	})
+	return findings
+}
+
+type linter struct {
+	cfg      Config
+	prog     *command.Program
+	findings []Finding
+	// halts caches whether procedures always halt
+	halts map[string]bool
+	// seen holds rule and position of reported findings so that statements
+	// executed several times are reported once
+	seen map[string]bool
+}
+
+// report records the finding of the rule at the position
+func (l *linter) report(pos command.Pos, id string, format string, args ...interface{}) {
+	key := id + "@" + pos.String()
+	if l.seen[key] {
+		return
+	}
+	l.seen[key] = true
+
+	for _, rule := range Rules {
+		if rule.ID == id {
+			l.findings = append(l.findings, Finding{
+				Pos:      pos,
+				Rule:     id,
+				Severity: rule.Severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+	}
+}
+
+// checkPlacement follows the statements in execution order until the robot is
+// placed and reports statements that are ignored before it, statements of
+// procedures are reported at the CALL they were executed by. Loops are
+// followed once as the robot is either placed by their first iteration or
+// by none, statements of a loop placing the robot are only reported when it
+// does not run again as the next iteration executes them placed
+func (l *linter) checkPlacement() {
+	placed := false
+	halted := false
+
+	var visit func(nodes []*command.Node, site *command.Node)
+	visit = func(nodes []*command.Node, site *command.Node) {
+		for _, n := range nodes {
+			if placed || halted {
+				return
+			}
+			at := n
+			if site != nil {
+				at = site
+			}
+
+			switch n.Name {
+			case "DEF", "SET":
+			case "INCLUDE":
+				if n.Include != nil {
+					visit(n.Include.Nodes, site)
+				}
+			case "CALL":
+				if len(n.Params) != 1 {
+					continue
+				}
+				if def, ok := l.prog.Procs[n.Params[0]]; ok {
+					visit(def.Body, at)
+				}
+			case "REPEAT":
+				if !repeats(n) {
+					continue
+				}
+				reported := len(l.findings)
+				visit(n.Body, site)
+				if placed && repeatsAgain(n) {
+					l.retract(reported)
+				}
+			case "HALT":
+				halted = true
+			case "PLACE":
+				placed = l.checkPlace(n)
+			case "REPORT":
+				l.report(at.Pos, "L005", "REPORT is executed before the first PLACE")
+			default:
+				if spec, ok := l.cfg.Registry.Lookup(n.Name); ok && spec.NeedsPlacement {
+					l.report(at.Pos, "L001", "%s is executed before the first PLACE", n.Name)
+				}
+			}
+		}
+	}
+	visit(l.prog.File.Nodes, nil)
+
+	// PLACE statements that were not followed are still checked for bounds
+	l.prog.Walk(func(n *command.Node) {
+		if n.Name == "PLACE" {
+			l.checkPlace(n)
+		}
+	})
+}
+
+// retract drops findings reported since the given number of findings so that
+// statements are reported again when executed at another place
+func (l *linter) retract(n int) {
+	for _, f := range l.findings[n:] {
+		delete(l.seen, f.Rule+"@"+f.Pos.String())
+	}
+	l.findings = l.findings[:n]
+}
+
+// checkPlace reports PLACE outside of the table and returns whether the
+// robot may be placed by it, positions depending on variables or on the
+// robot are assumed to be on the table
+func (l *linter) checkPlace(n *command.Node) bool {
+	if len(n.Args) < 2 {
+		return true
+	}
+
+	lookup := func(name string) (int, error) {
+		switch name {
+		case "WIDTH":
+			return int(l.cfg.SizeX), nil
+		case "HEIGHT":
+			return int(l.cfg.SizeY), nil
+		}
+		return 0, fmt.Errorf("%w: %s", expr.ErrUndefined, name)
+	}
+	x, err := n.Args[0].Expr.Eval(lookup)
+	if err != nil {
+		return true
+	}
+	y, err := n.Args[1].Expr.Eval(lookup)
+	if err != nil {
+		return true
+	}
+
+	if x < 0 || y < 0 || x >= int(l.cfg.SizeX) || y >= int(l.cfg.SizeY) {
+		l.report(n.Pos, "L002", "PLACE position (%d, %d) is outside of the %dx%d table", x, y, l.cfg.SizeX, l.cfg.SizeY)
+		return false
+	}
+	return true
+}
+
+// checkList checks consecutive statements of a file or a block
+func (l *linter) checkList(nodes []*command.Node) {
+	var halt *command.Node
+	run := 0
+	for i, n := range nodes {
+		if n.Name == "DEF" {
+			continue
+		}
+		if halt != nil {
+			l.report(n.Pos, "L006", "%s is unreachable as the run halts at %s", n.Name, halt.Pos)
+			return
+		}
+		if l.halting(n) {
+			halt = n
+		}
+
+		if i > 0 {
+			prev := nodes[i-1]
+			switch prev.Name + " " + n.Name {
+			case "LEFT RIGHT", "RIGHT LEFT", "UTURN UTURN":
+				l.report(prev.Pos, "L003", "%s followed by %s has no effect", prev.Name, n.Name)
+			}
+		}
+
+		if n.Name != "LEFT" && n.Name != "RIGHT" {
+			run = 0
+			continue
+		}
+		if run > 0 && nodes[i-1].Name == n.Name {
+			run++
+		} else {
+			run = 1
+		}
+		if run == 4 {
+			l.report(nodes[i-3].Pos, "L004", "four consecutive %s have no effect", n.Name)
+			run = 0
+		}
+	}
+}
+
+// halting reports whether the statement always stops the run, CALL and
+// INCLUDE stop it when the statements they execute do
+func (l *linter) halting(n *command.Node) bool {
+	switch n.Name {
+	case "HALT":
+		return true
+	case "INCLUDE":
+		return n.Include != nil && l.haltingList(n.Include.Nodes)
+	case "REPEAT":
+		return repeats(n) && l.haltingList(n.Body)
+	case "CALL":
+		if len(n.Params) != 1 {
+			return false
+		}
+		name := n.Params[0]
+		halts, ok := l.halts[name]
+		if ok {
+			return halts
+		}
+		def, ok := l.prog.Procs[name]
+		if !ok {
+			return false
+		}
+		// recursive calls are rejected by the loader, guard against them
+		// anyway in case the program was loaded with errors
+		l.halts[name] = false
+		l.halts[name] = l.haltingList(def.Body)
+		return l.halts[name]
+	}
+	return false
+}
+
+// haltingList reports whether any of the statements always stops the run
+func (l *linter) haltingList(nodes []*command.Node) bool {
+	for _, n := range nodes {
+		if n.Name != "DEF" && l.halting(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeats reports whether the REPEAT block is executed at least once, counts
+// that are not constant are assumed to be positive
+func repeats(n *command.Node) bool {
+	if len(n.Args) != 1 {
+		return false
+	}
+	count, ok := n.Args[0].Expr.(expr.Num)
+	return !ok || count > 0
+}
+
+// repeatsAgain reports whether the REPEAT block may be executed more than
+// once, counts that are not constant are assumed to be
+func repeatsAgain(n *command.Node) bool {
+	if len(n.Args) != 1 {
+		return false
+	}
+	count, ok := n.Args[0].Expr.(expr.Num)
+	return !ok || count > 1
+}
+
+// suppress drops findings of rules disabled in the file they were found in
+func suppress(prog *command.Program, findings []Finding) []Finding {
+	disabled := map[string]map[string]bool{}
+	files := []*command.File{prog.File}
+	prog.Walk(func(n *command.Node) {
+		if n.Include != nil {
+			files = append(files, n.Include)
+		}
+	})
+	for _, f := range files {
+		for _, c := range f.Comments {
+			text := strings.TrimSpace(c.Text)
+			if !strings.HasPrefix(text, "lint:disable") {
+				continue
+			}
+			if disabled[f.Name] == nil {
+				disabled[f.Name] = map[string]bool{}
+			}
+			ids := strings.TrimSpace(strings.TrimPrefix(text, "lint:disable"))
+			if ids == "" {
+				disabled[f.Name]["*"] = true
+				continue
+			}
+			for _, id := range strings.Split(ids, ",") {
+				disabled[f.Name][strings.ToUpper(strings.TrimSpace(id))] = true
+			}
+		}
+	}
+
+	kept := []Finding{}
+	for _, finding := range findings {
+		rules := disabled[finding.Pos.File]
+		if rules["*"] || rules[finding.Rule] {
+			continue
+		}
+		kept = append(kept, finding)
+	}
+	return kept
+}
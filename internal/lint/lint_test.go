@@ -0,0 +1,157 @@
+package lint_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/lint"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "should report commands before the first place",
+			files: map[string]string{
+				"main.txt": "SET n = 2\nMOVE n\nREPORT\nPLACE 0,0,NORTH\nMOVE\nREPORT\n",
+			},
+			expected: []string{
+				"main.txt:2: L001 warning: MOVE is executed before the first PLACE",
+				"main.txt:3: L005 warning: REPORT is executed before the first PLACE",
+			},
+		},
+		{
+			name: "should report commands of procedures at the call before the first place",
+			files: map[string]string{
+				"main.txt": "DEF P\n  LEFT\n  PLACE 0,0,NORTH\n  LEFT\nEND\nCALL P\nCALL P\n",
+			},
+			expected: []string{
+				"main.txt:6: L001 warning: LEFT is executed before the first PLACE",
+			},
+		},
+		{
+			name: "should report place outside of the table",
+			files: map[string]string{
+				"main.txt": "PLACE WIDTH,0,NORTH\nREPORT\nPLACE -1,HEIGHT-1,EAST\nPLACE POSX,5,EAST\nPLACE 4,4,EAST\nREPORT\n",
+			},
+			expected: []string{
+				"main.txt:1: L002 error: PLACE position (5, 0) is outside of the 5x5 table",
+				"main.txt:2: L005 warning: REPORT is executed before the first PLACE",
+				"main.txt:3: L002 error: PLACE position (-1, 4) is outside of the 5x5 table",
+			},
+		},
+		{
+			name: "should report redundant rotations",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nLEFT\nRIGHT\nUTURN\nUTURN\nRIGHT\nRIGHT\nRIGHT\nRIGHT\nRIGHT\nLEFT\nLEFT\nLEFT\n",
+			},
+			expected: []string{
+				"main.txt:2: L003 warning: LEFT followed by RIGHT has no effect",
+				"main.txt:4: L003 warning: UTURN followed by UTURN has no effect",
+				"main.txt:6: L004 warning: four consecutive RIGHT have no effect",
+				"main.txt:10: L003 warning: RIGHT followed by LEFT has no effect",
+			},
+		},
+		{
+			name: "should report statements following halt",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nINCLUDE \"stop.txt\"\nDEF P\n  HALT\nEND\nREPORT\n",
+				"stop.txt": "CALL P\nMOVE\n",
+			},
+			expected: []string{
+				"main.txt:6: L006 warning: REPORT is unreachable as the run halts at main.txt:2",
+				"stop.txt:2: L006 warning: MOVE is unreachable as the run halts at stop.txt:1",
+			},
+		},
+		{
+			name: "should check statements of loops",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nREPEAT 0\n  HALT\nEND\nREPEAT 2\n  LEFT\n  RIGHT\n  HALT\nEND\nREPORT\n",
+			},
+			expected: []string{
+				"main.txt:6: L003 warning: LEFT followed by RIGHT has no effect",
+				"main.txt:10: L006 warning: REPORT is unreachable as the run halts at main.txt:5",
+			},
+		},
+		{
+			name: "should not report commands of loops placing the robot on a later iteration",
+			files: map[string]string{
+				"main.txt": "REPEAT 2\n  MOVE\n  REPORT\n  PLACE 0,0,NORTH\nEND\nREPEAT 1\n  LEFT\nEND\n",
+			},
+			expected: []string{},
+		},
+		{
+			name: "should report commands of loops placing the robot on their only iteration",
+			files: map[string]string{
+				"main.txt": "REPEAT 1\n  MOVE\n  REPORT\n  PLACE 0,0,NORTH\nEND\n",
+			},
+			expected: []string{
+				"main.txt:2: L001 warning: MOVE is executed before the first PLACE",
+				"main.txt:3: L005 warning: REPORT is executed before the first PLACE",
+			},
+		},
+		{
+			name: "should suppress disabled rules per file",
+			files: map[string]string{
+				"main.txt":  "# lint:disable L003, l005\nREPORT\nINCLUDE \"turns.txt\"\nPLACE 0,0,NORTH\nLEFT\nRIGHT\n",
+				"turns.txt": "# lint:disable\nMOVE\nLEFT\nRIGHT\n",
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			readFile := func(fileName string) ([]byte, error) {
+				src, ok := tt.files[fileName]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", os.ErrNotExist, fileName)
+				}
+				return []byte(src), nil
+			}
+			prog, err := command.Load("main.txt", command.WithReadFile(readFile))
+			require.NoError(t, err)
+
+			findings := []string{}
+			for _, finding := range lint.Lint(prog, lint.Config{SizeX: 5, SizeY: 5}) {
+				findings = append(findings, finding.String())
+			}
+			require.Equal(t, tt.expected, findings)
+		})
+	}
+}
+
+func TestLintCustomCommands(t *testing.T) {
+	t.Parallel()
+
+	registry := command.NewRegistry()
+	exec := func(t command.Table, env *command.Env, args []command.Value) error {
+		return nil
+	}
+	require.NoError(t, registry.Register(command.Spec{Name: "BEEP", Help: "beeps", Exec: exec}))
+	require.NoError(t, registry.Register(command.Spec{Name: "SCAN", Help: "scans ahead", NeedsPlacement: true, Exec: exec}))
+
+	readFile := func(fileName string) ([]byte, error) {
+		return []byte("BEEP\nSCAN\nPLACE 0,0,NORTH\n"), nil
+	}
+	prog, err := command.Load("main.txt", command.WithReadFile(readFile), command.WithRegistry(registry))
+	require.NoError(t, err)
+
+	findings := []string{}
+	for _, finding := range lint.Lint(prog, lint.Config{SizeX: 5, SizeY: 5, Registry: registry}) {
+		findings = append(findings, finding.String())
+	}
+	require.Equal(t, []string{"main.txt:2: L001 warning: SCAN is executed before the first PLACE"}, findings)
+}
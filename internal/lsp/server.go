@@ -0,0 +1,578 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"robot/internal/command"
+	"robot/internal/jsonrpc"
+	"robot/internal/table"
+)
+
+// maxHoverStates limits the number of robot states shown for a single line
+const maxHoverStates = 8
+
+const (
+	// DefaultMaxSteps is the number of commands a simulation may execute
+	// unless configured otherwise
+	DefaultMaxSteps = 100000
+	// DefaultTimeout is the time a simulation may take unless configured
+	// otherwise
+	DefaultTimeout = time.Second
+)
+
+// keywords are handled by the command file scanner instead of the registry
+var keywords = map[string]string{
+	"INCLUDE": "INCLUDE \"file\"\n\nincludes commands of the file, the path is relative to this file",
+	"DEF":     "DEF name\n\nstarts definition of a procedure that ends with END",
+	"END":     "END\n\nends definition of a procedure or a loop",
+	"CALL":    "CALL name\n\nexecutes commands of the procedure",
+	"REPEAT":  "REPEAT count\n\nstarts a loop executing the commands until END the given number of times",
+}
+
+// Config defines the table documents are simulated on to show robot states
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// Start are PLACE parameters the robot is placed with before the
+	// document is simulated, the robot starts unplaced when empty
+	Start string
+	// MaxSteps limits the number of commands executed by a simulation,
+	// `DefaultMaxSteps` is used when zero
+	MaxSteps int
+	// Timeout limits the time a simulation may take, `DefaultTimeout` is used
+	// when zero
+	Timeout time.Duration
+}
+
+// Server is a language server for command files
+type Server struct {
+	conn     *jsonrpc.Conn
+	cfg      Config
+	registry *command.Registry
+	docs     map[string]string
+	results  map[string]*result
+}
+
+// result of a document analysis
+type result struct {
+	path string
+	prog *command.Program
+	// states hold descriptions of the robot after each line of the document
+	states map[int][]string
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Server)
+
+// WithRegistry provides an option to use custom command registry
+func WithRegistry(r *command.Registry) Option {
+	return func(s *Server) {
+		s.registry = r
+	}
+}
+
+func New(cfg Config, opts ...Option) *Server {
+	s := &Server{
+		cfg:      cfg,
+		registry: command.DefaultRegistry,
+		docs:     map[string]string{},
+		results:  map[string]*result{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Serve speaks the Language Server Protocol over the reader and writer until
+// the client asks the server to exit or closes the input
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = jsonrpc.NewConn(jsonrpc.NewHeaderCodec(r, w))
+	return s.conn.Serve(s.handle)
+}
+
+func (s *Server) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		var p InitializeParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := s.configure(p.InitializationOptions); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   1,
+				CompletionProvider: &CompletionOptions{TriggerCharacters: []string{" ", ","}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "robot"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "exit":
+		return nil, jsonrpc.ErrStop
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		return nil, s.analyzeAll()
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) > 0 {
+			s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		return nil, s.analyzeAll()
+	case "textDocument/didSave":
+		return nil, s.analyzeAll()
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		delete(s.results, p.TextDocument.URI)
+		return nil, s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.completion(p), nil
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.hover(p), nil
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.definition(p), nil
+	}
+
+	if strings.HasPrefix(method, "$/") {
+		return nil, nil
+	}
+	return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// configure applies initialization options sent by the client
+func (s *Server) configure(opts *Options) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.Size != "" {
+		sizeX, sizeY, err := table.ParseSize(opts.Size)
+		if err != nil {
+			return err
+		}
+		s.cfg.SizeX, s.cfg.SizeY = sizeX, sizeY
+	}
+	if opts.Start != "" {
+		s.cfg.Start = opts.Start
+	}
+	return nil
+}
+
+// analyzeAll analyzes every open document as any of them may include the
+// one that changed
+func (s *Server) analyzeAll() error {
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		res, diags := s.analyze(uri)
+		s.results[uri] = res
+		if err := s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diags}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyze loads the document and simulates it on the configured table
+func (s *Server) analyze(uri string) (*result, []Diagnostic) {
+	res := &result{
+		path:   uriToPath(uri),
+		states: map[int][]string{},
+	}
+	diags := []Diagnostic{}
+
+	prog, err := command.Load(res.path, command.WithRegistry(s.registry), command.WithReadFile(s.readFile))
+	res.prog = prog
+	if err != nil {
+		var errs command.ScanErrors
+		if !errors.As(err, &errs) {
+			return res, append(diags, diagnostic(0, SeverityError, err.Error()))
+		}
+		for _, err := range errs {
+			line, msg := s.locate(res.path, err)
+			diags = append(diags, diagnostic(line, SeverityError, msg))
+		}
+		return res, diags
+	}
+
+	if err := s.simulate(res); err != nil {
+		var perr *command.PartialError
+		if errors.As(err, &perr) {
+			return res, append(diags, s.stopped(res.path, perr))
+		}
+		line, msg := s.locate(res.path, err)
+		diags = append(diags, diagnostic(line, SeverityWarning, msg))
+	}
+	return res, diags
+}
+
+// stopped returns the diagnostic of a simulation that ran out of its budget,
+// it is reported at the latest line of the document that was executed
+func (s *Server) stopped(path string, perr *command.PartialError) Diagnostic {
+	line := 0
+	if perr.Pos.File == path {
+		line = perr.Pos.Line - 1
+	}
+	msg := fmt.Sprintf("simulation stopped after %d steps: %s", perr.Steps, perr.Err)
+	if errors.Is(perr, context.DeadlineExceeded) {
+		msg = fmt.Sprintf("simulation stopped after %d steps: took longer than %s", perr.Steps, s.timeout())
+	}
+	return diagnostic(line, SeverityWarning, msg)
+}
+
+// simulate runs the program on the configured table within the step budget
+// and the timeout, and records states of the robot after each line of the
+// document
+func (s *Server) simulate(res *result) error {
+	tbl := table.New(s.cfg.SizeX, s.cfg.SizeY, table.WithReportOutput(io.Discard))
+	if s.cfg.Start != "" {
+		place, err := s.registry.Parse("PLACE " + s.cfg.Start)
+		if err != nil {
+			return fmt.Errorf("invalid start placement: %w", err)
+		}
+		if err := command.Run(tbl, []command.Command{place}); err != nil {
+			return fmt.Errorf("invalid start placement: %w", err)
+		}
+	}
+
+	maxSteps := s.cfg.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	return command.RunContext(ctx, tbl, res.prog.Cmds, command.WithMaxSteps(maxSteps), command.WithStepHook(func(pos command.Pos, t command.Table, err error) {
+		if pos.File != res.path {
+			return
+		}
+		state := describe(t)
+		if err != nil {
+			state = fmt.Sprintf("%s, refused: %s", state, err)
+		}
+		for _, seen := range res.states[pos.Line] {
+			if seen == state {
+				return
+			}
+		}
+		res.states[pos.Line] = append(res.states[pos.Line], state)
+	}))
+}
+
+// timeout returns the time a simulation may take
+func (s *Server) timeout() time.Duration {
+	if s.cfg.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return s.cfg.Timeout
+}
+
+// describe returns robot position and facing on the table
+func describe(t command.Table) string {
+	pos, facing, err := t.Robot()
+	if err != nil {
+		return "robot is not placed"
+	}
+	return fmt.Sprintf("(%d, %d) %s", pos.X, pos.Y, facing)
+}
+
+// locate returns zero based line of the document and message of the error,
+// errors in included files are reported at the INCLUDE statement
+func (s *Server) locate(path string, err error) (int, string) {
+	for err != nil {
+		var pos command.Pos
+		var inner error
+		switch e := err.(type) {
+		case *command.ScanError:
+			pos, inner = e.Pos, e.Err
+		case *command.ExecError:
+			pos, inner = e.Pos, e.Err
+		default:
+			return 0, err.Error()
+		}
+		if pos.File == path {
+			return pos.Line - 1, inner.Error()
+		}
+		err = inner
+	}
+	return 0, ""
+}
+
+func diagnostic(line int, severity int, msg string) Diagnostic {
+	return Diagnostic{
+		Range:    lineRange(line),
+		Severity: severity,
+		Source:   "robot",
+		Message:  msg,
+	}
+}
+
+func lineRange(line int) Range {
+	return Range{Start: Position{Line: line}, End: Position{Line: line + 1}}
+}
+
+// readFile reads open documents from memory and other files from disk
+func (s *Server) readFile(fileName string) ([]byte, error) {
+	absName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for uri, text := range s.docs {
+		if uriToPath(uri) == absName {
+			return []byte(text), nil
+		}
+	}
+	return os.ReadFile(fileName)
+}
+
+// line returns the text of the zero based line of the document
+func (s *Server) line(uri string, line int) string {
+	lines := strings.Split(s.docs[uri], "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line], "\r")
+}
+
+// utf16Prefix returns the text before the character offset, the protocol
+// counts offsets in UTF-16 code units
+func utf16Prefix(line string, character int) string {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return line[:i]
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return line
+}
+
+// nodeAt returns the statement of the document at the zero based line
+func (s *Server) nodeAt(uri string, line int) (*result, *command.Node) {
+	res := s.results[uri]
+	if res == nil || res.prog == nil {
+		return res, nil
+	}
+
+	var found *command.Node
+	res.prog.Walk(func(n *command.Node) {
+		if n.Pos.File == res.path && n.Pos.Line == line+1 {
+			found = n
+		}
+	})
+	return res, found
+}
+
+func (s *Server) completion(p TextDocumentPositionParams) []CompletionItem {
+	prefix := utf16Prefix(s.line(p.TextDocument.URI, p.Position.Line), p.Position.Character)
+	txt := strings.TrimLeft(prefix, " \t")
+
+	fields := strings.Fields(txt)
+	if len(fields) == 0 || (len(fields) == 1 && !strings.HasSuffix(txt, " ") && !strings.HasSuffix(txt, "\t")) {
+		return s.keywordItems()
+	}
+
+	res := s.results[p.TextDocument.URI]
+	keyword := strings.ToUpper(fields[0])
+	if keyword == "CALL" {
+		items := []CompletionItem{}
+		if res != nil && res.prog != nil {
+			for name, def := range res.prog.Procs {
+				items = append(items, CompletionItem{Label: name, Kind: KindFunction, Detail: fmt.Sprintf("defined at %s", def.Pos)})
+			}
+		}
+		sortItems(items)
+		return items
+	}
+
+	spec, ok := s.registry.Lookup(keyword)
+	if !ok {
+		return []CompletionItem{}
+	}
+	argIdx := strings.Count(txt, ",")
+	if argIdx >= len(spec.Args) {
+		return []CompletionItem{}
+	}
+
+	items := []CompletionItem{}
+	switch arg := spec.Args[argIdx]; arg.Kind {
+	case command.ArgDirection:
+		for _, d := range []string{"NORTH", "EAST", "SOUTH", "WEST"} {
+			items = append(items, CompletionItem{Label: d, Kind: KindValue, Detail: arg.Name})
+		}
+	case command.ArgKeyword:
+		for _, choice := range arg.Choices {
+			items = append(items, CompletionItem{Label: choice, Kind: KindKeyword, Detail: arg.Name})
+		}
+	case command.ArgExpr:
+		for _, name := range command.Builtins() {
+			items = append(items, CompletionItem{Label: name, Kind: KindConstant, Detail: "built-in value"})
+		}
+		if res != nil && res.prog != nil {
+			seen := map[string]bool{}
+			res.prog.Walk(func(n *command.Node) {
+				for _, v := range n.Args {
+					if n.Name == "SET" && !seen[v.Name] {
+						seen[v.Name] = true
+						items = append(items, CompletionItem{Label: v.Name, Kind: KindVariable, Detail: "variable"})
+					}
+				}
+			})
+		}
+	}
+	sortItems(items)
+	return items
+}
+
+// keywordItems returns completion items of every command keyword
+func (s *Server) keywordItems() []CompletionItem {
+	items := []CompletionItem{}
+	for _, spec := range s.registry.Specs() {
+		items = append(items, CompletionItem{Label: spec.Name, Kind: KindKeyword, Detail: spec.Usage(), Documentation: spec.Help})
+	}
+	for name, doc := range keywords {
+		usage := strings.SplitN(doc, "\n", 2)[0]
+		items = append(items, CompletionItem{Label: name, Kind: KindKeyword, Detail: usage, Documentation: strings.TrimSpace(strings.TrimPrefix(doc, usage))})
+	}
+	sortItems(items)
+	return items
+}
+
+func sortItems(items []CompletionItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+}
+
+func (s *Server) hover(p TextDocumentPositionParams) *Hover {
+	fields := strings.Fields(s.line(p.TextDocument.URI, p.Position.Line))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	keyword := strings.ToUpper(fields[0])
+	if spec, ok := s.registry.Lookup(keyword); ok {
+		fmt.Fprintf(&sb, "```\n%s\n```\n%s\n", spec.Usage(), spec.Help)
+	} else if doc, ok := keywords[keyword]; ok {
+		parts := strings.SplitN(doc, "\n\n", 2)
+		fmt.Fprintf(&sb, "```\n%s\n```\n%s\n", parts[0], parts[1])
+	}
+
+	if res := s.results[p.TextDocument.URI]; res != nil {
+		if states := res.states[p.Position.Line+1]; len(states) > 0 {
+			start := "unplaced robot"
+			if s.cfg.Start != "" {
+				start = strings.ToUpper(s.cfg.Start)
+			}
+			fmt.Fprintf(&sb, "\nRobot after this line (start %s on %dx%d table):\n", start, s.cfg.SizeX, s.cfg.SizeY)
+			for i, state := range states {
+				if i == maxHoverStates {
+					fmt.Fprintf(&sb, "- %d more\n", len(states)-i)
+					break
+				}
+				fmt.Fprintf(&sb, "- %s\n", state)
+			}
+		}
+	}
+
+	if sb.Len() == 0 {
+		return nil
+	}
+	r := lineRange(p.Position.Line)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: sb.String()},
+		Range:    &r,
+	}
+}
+
+func (s *Server) definition(p TextDocumentPositionParams) []Location {
+	res, n := s.nodeAt(p.TextDocument.URI, p.Position.Line)
+	if n == nil {
+		return nil
+	}
+
+	switch n.Name {
+	case "CALL":
+		if len(n.Params) != 1 {
+			return nil
+		}
+		if def, ok := res.prog.Procs[n.Params[0]]; ok {
+			return []Location{{URI: pathToURI(def.Pos.File), Range: lineRange(def.Pos.Line - 1)}}
+		}
+	case "INCLUDE":
+		if n.Include != nil {
+			return []Location{{URI: pathToURI(n.Include.Name), Range: lineRange(0)}}
+		}
+	}
+	return nil
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+}
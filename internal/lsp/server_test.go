@@ -0,0 +1,224 @@
+package lsp_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/jsonrpc"
+	"robot/internal/lsp"
+)
+
+type client struct {
+	t      *testing.T
+	codec  jsonrpc.Codec
+	msgs   chan *jsonrpc.Message
+	nextID int
+	diags  map[string][]lsp.Diagnostic
+	done   chan error
+}
+
+func newClient(t *testing.T, cfg lsp.Config) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{
+		t:     t,
+		codec: jsonrpc.NewHeaderCodec(clientIn, clientOut),
+		msgs:  make(chan *jsonrpc.Message, 64),
+		diags: map[string][]lsp.Diagnostic{},
+		done:  make(chan error, 1),
+	}
+	go func() {
+		c.done <- lsp.New(cfg).Serve(serverIn, serverOut)
+		serverOut.Close()
+	}()
+	// messages are read in the background as the server may publish
+	// diagnostics while the client is still writing
+	go func() {
+		defer close(c.msgs)
+		for {
+			msg, err := c.codec.ReadMessage()
+			if err != nil {
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) notify(method string, params interface{}) {
+	raw, err := json.Marshal(params)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.codec.WriteMessage(&jsonrpc.Message{Version: jsonrpc.Version, Method: method, Params: raw}))
+}
+
+// call sends the request and reads messages until its response arrives,
+// diagnostics published meanwhile are collected
+func (c *client) call(method string, params interface{}, result interface{}) *jsonrpc.Error {
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	raw, err := json.Marshal(params)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.codec.WriteMessage(&jsonrpc.Message{Version: jsonrpc.Version, ID: id, Method: method, Params: raw}))
+
+	for msg := range c.msgs {
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var p lsp.PublishDiagnosticsParams
+			require.NoError(c.t, json.Unmarshal(msg.Params, &p))
+			c.diags[p.URI] = p.Diagnostics
+			continue
+		}
+		require.Equal(c.t, string(id), string(msg.ID))
+		if msg.Error != nil {
+			return msg.Error
+		}
+		require.NoError(c.t, json.Unmarshal(msg.Result, result))
+		return nil
+	}
+	c.t.Fatal("connection closed before the response arrived")
+	return nil
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	setup := filepath.Join(dir, "setup.txt")
+	require.NoError(t, os.WriteFile(setup, []byte("PLACE 0,0,NORTH\n"), 0o644))
+	mainURI := fileURI(filepath.Join(dir, "main.txt"))
+	brokenURI := fileURI(filepath.Join(dir, "broken.txt"))
+
+	c := newClient(t, lsp.Config{SizeX: 5, SizeY: 5, MaxSteps: 1000})
+
+	var initResult lsp.InitializeResult
+	require.Nil(t, c.call("initialize", map[string]interface{}{"initializationOptions": lsp.Options{Size: "3x3"}}, &initResult))
+	require.True(t, initResult.Capabilities.HoverProvider)
+	c.notify("initialized", struct{}{})
+
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{TextDocument: lsp.TextDocumentItem{
+		URI:  mainURI,
+		Text: "INCLUDE \"setup.txt\"\nDEF forward\n  MOVE\nEND\nCALL forward\nCALL forward\nCALL FORWARD\nREPORT\n",
+	}})
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{TextDocument: lsp.TextDocumentItem{
+		URI:  brokenURI,
+		Text: "PLACE 0,0,NORTH\nJUMP\nCALL nowhere\n",
+	}})
+
+	t.Run("should complete command keywords", func(t *testing.T) {
+		var items []lsp.CompletionItem
+		require.Nil(t, c.call("textDocument/completion", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+			Position:     lsp.Position{Line: 7, Character: 2},
+		}, &items))
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		require.Contains(t, labels, "REPORT")
+		require.Contains(t, labels, "CALL")
+	})
+
+	t.Run("should publish diagnostics from the parser", func(t *testing.T) {
+		require.Empty(t, c.diags[mainURI])
+		require.Len(t, c.diags[brokenURI], 2)
+		require.Equal(t, 1, c.diags[brokenURI][0].Range.Start.Line)
+		require.Equal(t, "invalid command detected: 'JUMP'", c.diags[brokenURI][0].Message)
+		require.Equal(t, 2, c.diags[brokenURI][1].Range.Start.Line)
+	})
+
+	t.Run("should show robot states on hover", func(t *testing.T) {
+		var hover lsp.Hover
+		require.Nil(t, c.call("textDocument/hover", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+			Position:     lsp.Position{Line: 2, Character: 3},
+		}, &hover))
+		require.Contains(t, hover.Contents.Value, "MOVE [steps][,mode]")
+		require.Contains(t, hover.Contents.Value, "Robot after this line (start unplaced robot on 3x3 table):\n- (0, 1) NORTH\n- (0, 2) NORTH\n- (0, 2) NORTH, refused: ending position out of bounds\n")
+	})
+
+	t.Run("should go to procedure definition", func(t *testing.T) {
+		var locs []lsp.Location
+		require.Nil(t, c.call("textDocument/definition", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+			Position:     lsp.Position{Line: 6, Character: 6},
+		}, &locs))
+		require.Equal(t, []lsp.Location{{URI: mainURI, Range: lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 2}}}}, locs)
+	})
+
+	t.Run("should go to included file", func(t *testing.T) {
+		var locs []lsp.Location
+		require.Nil(t, c.call("textDocument/definition", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+			Position:     lsp.Position{Line: 0, Character: 10},
+		}, &locs))
+		require.Len(t, locs, 1)
+		require.Equal(t, fileURI(setup), locs[0].URI)
+	})
+
+	t.Run("should complete directions of place command", func(t *testing.T) {
+		c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.TextDocumentIdentifier{URI: brokenURI},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "SET x = 1\nPLACE x,0,\n"}},
+		})
+		var items []lsp.CompletionItem
+		require.Nil(t, c.call("textDocument/completion", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: brokenURI},
+			Position:     lsp.Position{Line: 1, Character: 10},
+		}, &items))
+		require.Len(t, items, 4)
+		require.Equal(t, "EAST", items[0].Label)
+	})
+
+	t.Run("should count characters in UTF-16 code units", func(t *testing.T) {
+		c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.TextDocumentIdentifier{URI: brokenURI},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "PLACE \U0001F600\U0001F600,0,NORTH\n"}},
+		})
+		// the cursor follows the y coordinate, the emojis take two units each
+		var items []lsp.CompletionItem
+		require.Nil(t, c.call("textDocument/completion", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: brokenURI},
+			Position:     lsp.Position{Line: 0, Character: 12},
+		}, &items))
+		require.NotEmpty(t, items)
+		require.Equal(t, "built-in value", items[0].Detail)
+	})
+
+	t.Run("should report exhausted step budget", func(t *testing.T) {
+		c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.TextDocumentIdentifier{URI: brokenURI},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "PLACE 0,0,NORTH\nREPEAT 1000000\n  LEFT\nEND\n"}},
+		})
+		var hover lsp.Hover
+		require.Nil(t, c.call("textDocument/hover", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: brokenURI},
+			Position:     lsp.Position{Line: 0, Character: 1},
+		}, &hover))
+		require.Len(t, c.diags[brokenURI], 1)
+		require.Equal(t, lsp.SeverityWarning, c.diags[brokenURI][0].Severity)
+		require.Equal(t, 2, c.diags[brokenURI][0].Range.Start.Line)
+		require.Equal(t, "simulation stopped after 1000 steps: step budget exhausted", c.diags[brokenURI][0].Message)
+	})
+
+	t.Run("should fail unknown method", func(t *testing.T) {
+		var result interface{}
+		rpcErr := c.call("robot/unknown", nil, &result)
+		require.NotNil(t, rpcErr)
+		require.Equal(t, jsonrpc.CodeMethodNotFound, rpcErr.Code)
+	})
+
+	var result interface{}
+	require.Nil(t, c.call("shutdown", nil, &result))
+	c.notify("exit", nil)
+	require.NoError(t, <-c.done)
+}
@@ -0,0 +1,316 @@
+package maze
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"robot/internal/direction"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// Algorithm decides how passages of the maze are carved
+type Algorithm int
+
+const (
+	// Backtracker carves long winding passages with few dead ends by a
+	// randomized depth first search
+	Backtracker Algorithm = iota
+	// Prim grows the maze from the entrance by carving a random wall of its
+	// border, which gives many short dead ends
+	Prim
+	// Rooms places rectangular rooms and joins them by corridors
+	Rooms
+)
+
+// Algorithms are all maze generation algorithms
+var Algorithms = []Algorithm{Backtracker, Prim, Rooms}
+
+// String returns the upper case name of Algorithm
+func (a Algorithm) String() string {
+	switch a {
+	case Prim:
+		return "PRIM"
+	case Rooms:
+		return "ROOMS"
+	default:
+		return "BACKTRACKER"
+	}
+}
+
+// ParseAlgorithm returns the algorithm of the case insensitive name
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range Algorithms {
+		if a.String() == strings.ToUpper(name) {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid maze algorithm: '%s'", name)
+}
+
+// Maze is a table layout where every free cell can be reached from the
+// entrance
+type Maze struct {
+	table.Layout
+	// Entrance is the south west corner
+	Entrance point.Point
+	// Exit is the free cell closest to the north east corner
+	Exit point.Point
+}
+
+// Generate returns the maze of the given size carved by the algorithm, the
+// same seed always gives the same maze. Passages run between cells with even
+// coordinates, so the northmost row and the eastmost column are blocked when
+// the size is even
+func Generate(sizeX, sizeY uint, algorithm Algorithm, seed int64) (*Maze, error) {
+	if sizeX == 0 || sizeY == 0 {
+		return nil, fmt.Errorf("maze size has to be at least 1x1")
+	}
+
+	g := &generator{
+		rnd:    rand.New(rand.NewSource(seed)),
+		sizeX:  int(sizeX),
+		sizeY:  int(sizeY),
+		cellsX: int(sizeX+1) / 2,
+		cellsY: int(sizeY+1) / 2,
+		free:   map[point.Point]bool{},
+	}
+	switch algorithm {
+	case Prim:
+		g.prim()
+	case Rooms:
+		g.rooms()
+	default:
+		g.backtracker()
+	}
+
+	m := &Maze{
+		Layout: table.Layout{SizeX: sizeX, SizeY: sizeY},
+		Exit:   g.pos(point.Point{X: g.cellsX - 1, Y: g.cellsY - 1}),
+	}
+	for y := 0; y < g.sizeY; y++ {
+		for x := 0; x < g.sizeX; x++ {
+			if pos := (point.Point{X: x, Y: y}); !g.free[pos] {
+				m.BlockedCells = append(m.BlockedCells, pos)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Start returns the entrance facing the only passage leading from it
+func (m *Maze) Start() table.Pose {
+	start := table.Pose{Pos: m.Entrance, Facing: direction.North}
+	if plan.Validate(m, point.Point{X: m.Entrance.X, Y: m.Entrance.Y + 1}) != nil {
+		start.Facing = direction.East
+	}
+	return start
+}
+
+// Solve returns the shortest steps taking the robot from the start to the exit
+func (m *Maze) Solve() ([]plan.Step, error) {
+	return plan.Plan(m, m.Start(), plan.Goal{Pos: m.Exit}, plan.DefaultCost)
+}
+
+// generator carves passages between cells, a cell at X,Y lies on the table
+// position 2X,2Y and the position between two neighbouring cells is the wall
+// separating them
+type generator struct {
+	rnd            *rand.Rand
+	sizeX, sizeY   int
+	cellsX, cellsY int
+	// free holds carved table positions
+	free map[point.Point]bool
+}
+
+// pos returns the table position of the cell
+func (g *generator) pos(cell point.Point) point.Point {
+	return point.Point{X: 2 * cell.X, Y: 2 * cell.Y}
+}
+
+// carve frees the cell and the wall towards its neighbour in the direction
+func (g *generator) carve(cell point.Point, d direction.Direction) {
+	pos := g.pos(cell)
+	g.free[pos] = true
+	g.free[d.Step(pos)] = true
+	g.free[point.Point{X: pos.X + 2*d.DX(), Y: pos.Y + 2*d.DY()}] = true
+}
+
+// neighbours returns the cells next to the cell alongside their directions
+func (g *generator) neighbours(cell point.Point) ([]point.Point, []direction.Direction) {
+	var cells []point.Point
+	var dirs []direction.Direction
+	for _, d := range direction.All() {
+		next := d.Step(cell)
+		if next.X >= 0 && next.Y >= 0 && next.X < g.cellsX && next.Y < g.cellsY {
+			cells = append(cells, next)
+			dirs = append(dirs, d)
+		}
+	}
+	return cells, dirs
+}
+
+// backtracker walks to a random unvisited neighbour and backtracks once there
+// is none
+func (g *generator) backtracker() {
+	start := point.Point{}
+	g.free[g.pos(start)] = true
+	visited := map[point.Point]bool{start: true}
+	stack := []point.Point{start}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+
+		var cells []point.Point
+		var dirs []direction.Direction
+		next, nextDirs := g.neighbours(cell)
+		for i, n := range next {
+			if !visited[n] {
+				cells = append(cells, n)
+				dirs = append(dirs, nextDirs[i])
+			}
+		}
+		if len(cells) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		i := g.rnd.Intn(len(cells))
+		g.carve(cell, dirs[i])
+		visited[cells[i]] = true
+		stack = append(stack, cells[i])
+	}
+}
+
+// wall separates the cell from its neighbour in the direction
+type wall struct {
+	cell point.Point
+	dir  direction.Direction
+}
+
+// next returns the neighbour the wall separates the cell from
+func (w wall) next() point.Point {
+	return w.dir.Step(w.cell)
+}
+
+// prim carves a random wall between the maze and an unvisited cell until
+// every cell is visited
+func (g *generator) prim() {
+	visited := map[point.Point]bool{}
+	var frontier []wall
+	visit := func(cell point.Point) {
+		visited[cell] = true
+		cells, dirs := g.neighbours(cell)
+		for i := range cells {
+			if !visited[cells[i]] {
+				frontier = append(frontier, wall{cell: cell, dir: dirs[i]})
+			}
+		}
+	}
+
+	g.free[g.pos(point.Point{})] = true
+	visit(point.Point{})
+	for len(frontier) > 0 {
+		i := g.rnd.Intn(len(frontier))
+		w := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		if visited[w.next()] {
+			continue
+		}
+		g.carve(w.cell, w.dir)
+		visit(w.next())
+	}
+}
+
+// rooms carves rooms which do not touch each other and joins them with the
+// remaining cells by carving random walls between parts that are not joined
+// yet, so that every room has a single door to each neighbouring part
+func (g *generator) rooms() {
+	parent := map[point.Point]point.Point{}
+	var find func(cell point.Point) point.Point
+	find = func(cell point.Point) point.Point {
+		p, ok := parent[cell]
+		if !ok || p == cell {
+			return cell
+		}
+		root := find(p)
+		parent[cell] = root
+		return root
+	}
+
+	inRoom := map[point.Point]bool{}
+	maxSide := max(2, min(g.cellsX, g.cellsY)/3)
+	for attempt := 0; attempt < g.cellsX*g.cellsY/4; attempt++ {
+		w, h := 2+g.rnd.Intn(maxSide-1), 2+g.rnd.Intn(maxSide-1)
+		if w > g.cellsX || h > g.cellsY {
+			continue
+		}
+		x0, y0 := g.rnd.Intn(g.cellsX-w+1), g.rnd.Intn(g.cellsY-h+1)
+
+		// rooms keep a cell apart so that corridors can run between them
+		overlaps := false
+		for y := y0 - 1; y <= y0+h && !overlaps; y++ {
+			for x := x0 - 1; x <= x0+w && !overlaps; x++ {
+				overlaps = inRoom[point.Point{X: x, Y: y}]
+			}
+		}
+		if overlaps {
+			continue
+		}
+
+		corner := point.Point{X: x0, Y: y0}
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				inRoom[point.Point{X: x, Y: y}] = true
+				parent[point.Point{X: x, Y: y}] = corner
+			}
+		}
+		for y := 2 * y0; y <= 2*(y0+h-1); y++ {
+			for x := 2 * x0; x <= 2*(x0+w-1); x++ {
+				g.free[point.Point{X: x, Y: y}] = true
+			}
+		}
+	}
+
+	var walls []wall
+	for y := 0; y < g.cellsY; y++ {
+		for x := 0; x < g.cellsX; x++ {
+			cell := point.Point{X: x, Y: y}
+			g.free[g.pos(cell)] = true
+			if x+1 < g.cellsX {
+				walls = append(walls, wall{cell: cell, dir: direction.East})
+			}
+			if y+1 < g.cellsY {
+				walls = append(walls, wall{cell: cell, dir: direction.North})
+			}
+		}
+	}
+	g.rnd.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+	for _, w := range walls {
+		a, b := find(w.cell), find(w.next())
+		if a == b {
+			continue
+		}
+		g.carve(w.cell, w.dir)
+		parent[b] = a
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
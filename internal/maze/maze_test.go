@@ -0,0 +1,112 @@
+package maze_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/cover"
+	"robot/internal/maze"
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name  string
+		sizeX uint
+		sizeY uint
+		seed  int64
+	}{
+		{
+			name:  "should generate single cell maze",
+			sizeX: 1,
+			sizeY: 1,
+			seed:  1,
+		},
+		{
+			name:  "should generate single row maze",
+			sizeX: 7,
+			sizeY: 1,
+			seed:  1,
+		},
+		{
+			name:  "should generate maze of even size",
+			sizeX: 8,
+			sizeY: 6,
+			seed:  2,
+		},
+		{
+			name:  "should generate maze of odd size",
+			sizeX: 21,
+			sizeY: 11,
+			seed:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		for _, algorithm := range maze.Algorithms {
+			algorithm := algorithm
+			t.Run(tt.name+" by "+algorithm.String(), func(t *testing.T) {
+				t.Parallel()
+
+				m, err := maze.Generate(tt.sizeX, tt.sizeY, algorithm, tt.seed)
+				require.NoError(t, err)
+				again, err := maze.Generate(tt.sizeX, tt.sizeY, algorithm, tt.seed)
+				require.NoError(t, err)
+				require.Equal(t, m, again)
+
+				layout, err := table.ParseLayout([]byte(m.String()))
+				require.NoError(t, err)
+				require.Equal(t, m.SizeX, layout.SizeX)
+				require.Equal(t, m.SizeY, layout.SizeY)
+				require.ElementsMatch(t, m.BlockedCells, layout.BlockedCells)
+
+				res, err := cover.Plan(m, m.Start(), cover.Boustrophedon)
+				require.NoError(t, err)
+				require.Equal(t, int(tt.sizeX*tt.sizeY)-len(m.BlockedCells), res.Reachable)
+				require.False(t, m.Blocked(m.Exit))
+
+				steps, err := m.Solve()
+				require.NoError(t, err)
+				cmds, err := command.ScanCommandList("maze.txt", command.WithReadFile(func(fileName string) ([]byte, error) {
+					return append(plan.Source(m.Start(), steps), "REPORT\n"...), nil
+				}))
+				require.NoError(t, err)
+
+				tbl := table.New(m.SizeX, m.SizeY, table.WithReportOutput(bytes.NewBufferString("")), table.WithBlocked(m.BlockedCells...))
+				require.NoError(t, command.Run(tbl, cmds))
+				pos, _, err := tbl.Robot()
+				require.NoError(t, err)
+				require.Equal(t, m.Exit, pos)
+			})
+		}
+	}
+}
+
+func TestGenerateSeeds(t *testing.T) {
+	t.Parallel()
+
+	for _, algorithm := range maze.Algorithms {
+		a, err := maze.Generate(21, 21, algorithm, 1)
+		require.NoError(t, err)
+		b, err := maze.Generate(21, 21, algorithm, 2)
+		require.NoError(t, err)
+		require.NotEqual(t, a.String(), b.String(), algorithm.String())
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := maze.Generate(0, 5, maze.Backtracker, 1)
+	require.EqualError(t, err, "maze size has to be at least 1x1")
+
+	_, err = maze.ParseAlgorithm("kruskal")
+	require.EqualError(t, err, "invalid maze algorithm: 'kruskal'")
+}
@@ -0,0 +1,7 @@
+package optimize
+
+import "errors"
+
+var (
+	ErrNotEquivalent error = errors.New("optimized program is not equivalent")
+)
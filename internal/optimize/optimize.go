@@ -0,0 +1,466 @@
+package optimize
+
+import (
+	"fmt"
+	"strconv"
+
+	"robot/internal/command"
+	"robot/internal/equiv"
+	"robot/internal/expr"
+)
+
+// maxPasses limits how many times the passes are repeated before the
+// program stops changing
+const maxPasses = 10
+
+// Config holds the table size the optimized program is checked on
+type Config struct {
+	SizeX uint
+	SizeY uint
+	// MaxSteps limits the number of commands executed by a single run of the
+	// equivalence check, see `equiv.Config`
+	MaxSteps int
+}
+
+// Optimize returns a program producing the same REPORT output as the loaded
+// one from every start state on the table, i.e. with the robot not placed
+// and placed at every pose. Includes and procedures are inlined, rotations
+// are folded, commands that can never have an effect are dropped and
+// repeated commands are collapsed into loops. The result is run against the
+// original program and ErrNotEquivalent is returned when they differ
+func Optimize(prog *command.Program, cfg Config) (*command.File, error) {
+	o := &optimizer{
+		cfg:  cfg,
+		prog: prog,
+	}
+
+	nodes := o.flatten(prog.File.Nodes)
+	for i := 0; i < maxPasses; i++ {
+		before := key(nodes)
+		nodes = o.optimize(nodes)
+		nodes = trimTail(nodes)
+		if key(nodes) == before {
+			break
+		}
+	}
+
+	f := &command.File{Name: prog.File.Name, Nodes: nodes}
+	if err := o.check(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type optimizer struct {
+	cfg  Config
+	prog *command.Program
+}
+
+// flatten returns copies of the statements in execution order with includes
+// and procedure calls inlined
+func (o *optimizer) flatten(nodes []*command.Node) []*command.Node {
+	flat := []*command.Node{}
+	for _, n := range nodes {
+		switch n.Name {
+		case "DEF":
+		case "INCLUDE":
+			if n.Include != nil {
+				flat = append(flat, o.flatten(n.Include.Nodes)...)
+			}
+		case "CALL":
+			if def, ok := o.prog.Procs[n.Params[0]]; ok {
+				flat = append(flat, o.flatten(def.Body)...)
+			}
+		case "REPEAT":
+			flat = append(flat, &command.Node{Name: n.Name, Params: n.Params, Body: o.flatten(n.Body)})
+		default:
+			flat = append(flat, &command.Node{Name: n.Name, Params: n.Params})
+		}
+	}
+	return flat
+}
+
+// optimize applies every pass to the statements and the loops among them
+func (o *optimizer) optimize(nodes []*command.Node) []*command.Node {
+	for _, n := range nodes {
+		if n.Name == "REPEAT" {
+			n.Body = o.optimize(n.Body)
+		}
+	}
+
+	nodes = dropUnreachable(nodes)
+	nodes = o.dropIneffective(nodes)
+	nodes = foldRotations(nodes)
+	nodes = collapseMoves(nodes)
+	return collapseLoops(nodes)
+}
+
+// dropUnreachable drops statements following the one that always halts
+func dropUnreachable(nodes []*command.Node) []*command.Node {
+	for i, n := range nodes {
+		if halts(n) {
+			return nodes[:i+1]
+		}
+	}
+	return nodes
+}
+
+// halts reports whether the statement always stops the run
+func halts(n *command.Node) bool {
+	switch n.Name {
+	case "HALT":
+		return true
+	case "REPEAT":
+		count, ok := constant(n.Params[0])
+		if !ok || count <= 0 {
+			return false
+		}
+		for _, child := range n.Body {
+			if halts(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trimTail drops statements following the last REPORT as they can not change
+// the output, it returns no statements when nothing is reported
+func trimTail(nodes []*command.Node) []*command.Node {
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if reports(nodes[i]) {
+			return nodes[:i+1]
+		}
+	}
+	return []*command.Node{}
+}
+
+// reports reports whether the statement may produce output, commands that are
+// not built-in are assumed to
+func reports(n *command.Node) bool {
+	switch n.Name {
+	case "REPORT":
+		return true
+	case "REPEAT":
+		for _, child := range n.Body {
+			if reports(child) {
+				return true
+			}
+		}
+		return false
+	case "PLACE", "SET", "MOVE", "BACK", "LEFT", "RIGHT", "UTURN", "TURN", "GOTO", "COVER", "EXPLORE", "HALT":
+		return false
+	}
+	return true
+}
+
+// dropIneffective drops statements that never change the robot and
+// statements whose effect is always overwritten by the following PLACE
+func (o *optimizer) dropIneffective(nodes []*command.Node) []*command.Node {
+	kept := []*command.Node{}
+	for _, n := range nodes {
+		switch {
+		case n.Name == "TURN" && isConstant(n.Params, 0):
+			continue
+		case (n.Name == "MOVE" || n.Name == "BACK") && len(n.Params) > 0 && isConstant(n.Params[:1], 0):
+			continue
+		case n.Name == "REPEAT" && (len(n.Body) == 0 || isConstant(n.Params, 0)):
+			continue
+		case n.Name == "PLACE" && o.placeOutside(n):
+			continue
+		case n.Name == "PLACE" && o.placeInside(n):
+			// the robot pose before a PLACE that always succeeds is lost
+			for len(kept) > 0 && o.overwritten(kept[len(kept)-1]) {
+				kept = kept[:len(kept)-1]
+			}
+		}
+		kept = append(kept, n)
+	}
+	return kept
+}
+
+// overwritten reports whether the statement only changes the robot pose and
+// can not fail
+func (o *optimizer) overwritten(n *command.Node) bool {
+	switch n.Name {
+	case "LEFT", "RIGHT", "UTURN":
+		return true
+	case "MOVE", "BACK":
+		if len(n.Params) == 0 {
+			return true
+		}
+		_, ok := constant(n.Params[0])
+		return ok
+	case "TURN":
+		_, ok := rotation(n)
+		return ok
+	case "PLACE":
+		_, _, ok := o.placePos(n)
+		return ok
+	}
+	return false
+}
+
+// placeOutside reports whether PLACE is always refused
+func (o *optimizer) placeOutside(n *command.Node) bool {
+	x, y, ok := o.placePos(n)
+	return ok && (x < 0 || y < 0 || x >= int(o.cfg.SizeX) || y >= int(o.cfg.SizeY))
+}
+
+// placeInside reports whether PLACE always succeeds
+func (o *optimizer) placeInside(n *command.Node) bool {
+	x, y, ok := o.placePos(n)
+	return ok && x >= 0 && y >= 0 && x < int(o.cfg.SizeX) && y < int(o.cfg.SizeY)
+}
+
+// placePos returns the position of PLACE when it does not depend on the
+// variables or the robot
+func (o *optimizer) placePos(n *command.Node) (int, int, bool) {
+	if len(n.Params) != 3 {
+		return 0, 0, false
+	}
+	x, ok := o.eval(n.Params[0])
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := o.eval(n.Params[1])
+	return x, y, ok
+}
+
+// eval evaluates the expression that may only reference the table size
+func (o *optimizer) eval(param string) (int, bool) {
+	x, err := expr.Parse(param)
+	if err != nil {
+		return 0, false
+	}
+	v, err := x.Eval(func(name string) (int, error) {
+		switch name {
+		case "WIDTH":
+			return int(o.cfg.SizeX), nil
+		case "HEIGHT":
+			return int(o.cfg.SizeY), nil
+		}
+		return 0, fmt.Errorf("%w: %s", expr.ErrUndefined, name)
+	})
+	return v, err == nil
+}
+
+// constant evaluates the expression without identifiers
+func constant(param string) (int, bool) {
+	x, err := expr.Parse(param)
+	if err != nil || len(expr.Idents(x)) > 0 {
+		return 0, false
+	}
+	v, err := x.Eval(nil)
+	return v, err == nil
+}
+
+// isConstant reports whether the only parameter evaluates to the value
+func isConstant(params []string, value int) bool {
+	if len(params) != 1 {
+		return false
+	}
+	v, ok := constant(params[0])
+	return ok && v == value
+}
+
+// foldRotations replaces consecutive rotations with a single one turning the
+// robot by the same angle
+func foldRotations(nodes []*command.Node) []*command.Node {
+	folded := []*command.Node{}
+	for i := 0; i < len(nodes); {
+		degrees, ok := rotation(nodes[i])
+		if !ok {
+			folded = append(folded, nodes[i])
+			i++
+			continue
+		}
+
+		j := i + 1
+		for ; j < len(nodes); j++ {
+			d, ok := rotation(nodes[j])
+			if !ok {
+				break
+			}
+			degrees += d
+		}
+		if j-i == 1 && nodes[i].Name != "TURN" {
+			folded = append(folded, nodes[i])
+		} else if n := rotate(degrees); n != nil {
+			folded = append(folded, n)
+		}
+		i = j
+	}
+	return folded
+}
+
+// rotation returns the counterclockwise angle the statement rotates by
+func rotation(n *command.Node) (int, bool) {
+	switch n.Name {
+	case "LEFT":
+		return 90, true
+	case "RIGHT":
+		return -90, true
+	case "UTURN":
+		return 180, true
+	case "TURN":
+		if len(n.Params) != 1 {
+			return 0, false
+		}
+		degrees, ok := constant(n.Params[0])
+		return degrees, ok && degrees%90 == 0
+	}
+	return 0, false
+}
+
+// rotate returns the shortest statement rotating by the angle, nil when the
+// angle is a full turn
+func rotate(degrees int) *command.Node {
+	switch (degrees%360 + 360) % 360 {
+	case 90:
+		return &command.Node{Name: "LEFT"}
+	case 180:
+		return &command.Node{Name: "UTURN"}
+	case 270:
+		return &command.Node{Name: "RIGHT"}
+	}
+	return nil
+}
+
+// collapseMoves replaces consecutive single step moves with a partial move
+// by several steps, moving step by step stops at the edge of the table the
+// same way as a partial move does
+func collapseMoves(nodes []*command.Node) []*command.Node {
+	collapsed := []*command.Node{}
+	for i := 0; i < len(nodes); {
+		n := nodes[i]
+		steps, ok := singleSteps(n)
+		if !ok {
+			collapsed = append(collapsed, n)
+			i++
+			continue
+		}
+
+		j := i + 1
+		for ; j < len(nodes) && nodes[j].Name == n.Name; j++ {
+			s, ok := singleSteps(nodes[j])
+			if !ok {
+				break
+			}
+			steps += s
+		}
+		if j-i == 1 {
+			collapsed = append(collapsed, n)
+		} else {
+			collapsed = append(collapsed, &command.Node{Name: n.Name, Params: []string{strconv.Itoa(steps), "PARTIAL"}})
+		}
+		i = j
+	}
+	return collapsed
+}
+
+// singleSteps returns the number of steps of MOVE and BACK that are made one
+// by one until the edge of the table, i.e. single step moves and partial
+// moves by a constant number of steps
+func singleSteps(n *command.Node) (int, bool) {
+	if n.Name != "MOVE" && n.Name != "BACK" {
+		return 0, false
+	}
+	switch len(n.Params) {
+	case 0:
+		return 1, true
+	case 2:
+		steps, ok := constant(n.Params[0])
+		return steps, ok && steps > 0 && n.Params[1] == "PARTIAL"
+	}
+	return 0, false
+}
+
+// collapseLoops replaces sequences of statements repeated one after another
+// with a loop when it makes the program shorter
+func collapseLoops(nodes []*command.Node) []*command.Node {
+	keys := make([]string, len(nodes))
+	for i, n := range nodes {
+		keys[i] = key([]*command.Node{n})
+	}
+
+	collapsed := []*command.Node{}
+	for i := 0; i < len(nodes); {
+		bestLen, bestCount, bestSaved := 0, 0, 0
+		for l := 1; i+2*l <= len(nodes); l++ {
+			count := 1
+			for repeated(keys, i, i+count*l, l) {
+				count++
+			}
+			// the loop adds REPEAT and END statements
+			if saved := size(nodes[i:i+l])*(count-1) - 2; count > 1 && saved > bestSaved {
+				bestLen, bestCount, bestSaved = l, count, saved
+			}
+		}
+
+		if bestCount == 0 {
+			collapsed = append(collapsed, nodes[i])
+			i++
+			continue
+		}
+		collapsed = append(collapsed, &command.Node{
+			Name:   "REPEAT",
+			Params: []string{strconv.Itoa(bestCount)},
+			Body:   nodes[i : i+bestLen],
+		})
+		i += bestLen * bestCount
+	}
+	return collapsed
+}
+
+// repeated reports whether the l statements starting at j repeat the ones
+// starting at i
+func repeated(keys []string, i, j, l int) bool {
+	if j+l > len(keys) {
+		return false
+	}
+	for k := 0; k < l; k++ {
+		if keys[i+k] != keys[j+k] {
+			return false
+		}
+	}
+	return true
+}
+
+// size returns the number of lines of the statements
+func size(nodes []*command.Node) int {
+	lines := 0
+	for _, n := range nodes {
+		lines++
+		if n.Name == "REPEAT" {
+			lines += size(n.Body) + 1
+		}
+	}
+	return lines
+}
+
+// key returns the canonical source of the statements used to compare them
+func key(nodes []*command.Node) string {
+	return string(command.Format(&command.File{Nodes: nodes}))
+}
+
+// check runs both programs from every start state and fails unless the
+// optimized program reports the same
+func (o *optimizer) check(f *command.File) error {
+	src := command.Format(f)
+	optimized, err := command.Load(f.Name, command.WithReadFile(func(fileName string) ([]byte, error) {
+		return src, nil
+	}))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotEquivalent, err.Error())
+	}
+
+	d, err := equiv.Check(o.prog, optimized, equiv.Config{SizeX: o.cfg.SizeX, SizeY: o.cfg.SizeY, MaxSteps: o.cfg.MaxSteps})
+	if err != nil {
+		return err
+	}
+	if d != nil {
+		return fmt.Errorf("%w: %s", ErrNotEquivalent, d)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package optimize_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/optimize"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "should fold rotations",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nLEFT\nLEFT\nLEFT\nREPORT\nLEFT\nRIGHT\nREPORT\nTURN 90\nUTURN\nTURN -360\nRIGHT\nREPORT\n",
+			},
+			expected: "PLACE 0,0,NORTH\nRIGHT\nREPORT\nREPORT\nUTURN\nREPORT\n",
+		},
+		{
+			name: "should drop commands that can never have an effect",
+			files: map[string]string{
+				"main.txt": "LEFT\nMOVE 2\nPLACE 1,1,EAST\nPLACE 9,9,EAST\nMOVE 0\nTURN 0\nREPEAT 0\n  REPORT\nEND\nREPORT\nMOVE\nHALT\nREPORT\n",
+			},
+			expected: "PLACE 1,1,EAST\nREPORT\n",
+		},
+		{
+			name: "should keep commands preceding place that depend on the robot",
+			files: map[string]string{
+				"main.txt": "MOVE\nSET x = POSX\nLEFT\nPLACE 0,x,EAST\nREPORT\nLEFT\nPLACE 0,0,EAST\nREPORT\n",
+			},
+			expected: "MOVE\nSET X = POSX\nLEFT\nPLACE 0,X,EAST\nREPORT\nPLACE 0,0,EAST\nREPORT\n",
+		},
+		{
+			name: "should collapse moves into partial moves",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nMOVE\nMOVE\nMOVE 2,PARTIAL\nMOVE\nREPORT\nBACK\nBACK\nMOVE 2\nMOVE 2\nREPORT\n",
+			},
+			expected: "PLACE 0,0,NORTH\nMOVE 5,PARTIAL\nREPORT\nBACK 2,PARTIAL\nMOVE 2\nMOVE 2\nREPORT\n",
+		},
+		{
+			name: "should inline procedures and collapse them into loops",
+			files: map[string]string{
+				"main.txt":  "INCLUDE \"procs.txt\"\nPLACE 0,0,NORTH\nCALL SIDE\nCALL SIDE\nCALL SIDE\nCALL SIDE\nREPORT\n",
+				"procs.txt": "DEF SIDE\n  MOVE\n  LEFT\n  LEFT\n  LEFT\n  REPORT\nEND\n",
+			},
+			expected: "PLACE 0,0,NORTH\nREPEAT 4\n  MOVE\n  RIGHT\n  REPORT\nEND\nREPORT\n",
+		},
+		{
+			name: "should drop programs that never report",
+			files: map[string]string{
+				"main.txt": "PLACE 0,0,NORTH\nMOVE\n",
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			readFile := func(fileName string) ([]byte, error) {
+				src, ok := tt.files[fileName]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", os.ErrNotExist, fileName)
+				}
+				return []byte(src), nil
+			}
+			prog, err := command.Load("main.txt", command.WithReadFile(readFile))
+			require.NoError(t, err)
+
+			f, err := optimize.Optimize(prog, optimize.Config{SizeX: 5, SizeY: 5})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(command.Format(f)))
+		})
+	}
+}
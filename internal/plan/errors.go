@@ -0,0 +1,7 @@
+package plan
+
+import "errors"
+
+var (
+	ErrUnreachable error = errors.New("goal is unreachable")
+)
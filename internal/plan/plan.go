@@ -0,0 +1,267 @@
+package plan
+
+import (
+	"container/heap"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// Step is a single command of the plan
+type Step int
+
+const (
+	// Move moves the robot forward by one step
+	Move Step = iota
+	// Left rotates the robot by 90 degrees counterclockwise
+	Left
+	// Right rotates the robot by 90 degrees clockwise
+	Right
+	// UTurn turns the robot around
+	UTurn
+)
+
+// String returns the command keyword of Step
+func (s Step) String() string {
+	switch s {
+	case Move:
+		return "MOVE"
+	case Left:
+		return "LEFT"
+	case Right:
+		return "RIGHT"
+	default:
+		return "UTURN"
+	}
+}
+
+// Cost holds the cost of each step, all of them have to be positive
+type Cost struct {
+	Move  int
+	Turn  int
+	UTurn int
+}
+
+// DefaultCost counts every move and quarter turn as a single step
+var DefaultCost = Cost{Move: 1, Turn: 1, UTurn: 2}
+
+// Grid is the table the path is planned on
+type Grid interface {
+	Size() (uint, uint)
+	Blocked(pos point.Point) bool
+}
+
+// Goal is the pose the robot should end up at
+type Goal struct {
+	Pos point.Point
+	// Facing is the facing the robot should end up with, any facing is
+	// accepted when nil
+	Facing *direction.Direction
+}
+
+// String returns a string representation of Goal in the X,Y[,F] form
+func (g Goal) String() string {
+	s := fmt.Sprintf("%d,%d", g.Pos.X, g.Pos.Y)
+	if g.Facing != nil {
+		s += "," + g.Facing.String()
+	}
+	return s
+}
+
+// ParseGoal parses goal given in the X,Y or X,Y,F form
+func ParseGoal(s string) (Goal, error) {
+	params := strings.Split(s, ",")
+	if len(params) == 3 {
+		pose, err := table.ParsePose(s)
+		if err != nil {
+			return Goal{}, err
+		}
+		return Goal{Pos: pose.Pos, Facing: &pose.Facing}, nil
+	}
+	if len(params) != 2 {
+		return Goal{}, fmt.Errorf("goal requires X,Y or X,Y,F form: '%s'", s)
+	}
+
+	x, err := strconv.Atoi(strings.TrimSpace(params[0]))
+	if err != nil {
+		return Goal{}, fmt.Errorf("goal x is not a number(%s)", strings.TrimSpace(params[0]))
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(params[1]))
+	if err != nil {
+		return Goal{}, fmt.Errorf("goal y is not a number(%s)", strings.TrimSpace(params[1]))
+	}
+	return Goal{Pos: point.Point{X: x, Y: y}}, nil
+}
+
+// Plan returns the cheapest steps taking the robot from the start to the goal
+// without leaving the grid or entering blocked cells. It uses A* with the
+// Manhattan distance to the goal as the heuristic
+func Plan(g Grid, start table.Pose, goal Goal, cost Cost) ([]Step, error) {
+	if cost.Move <= 0 || cost.Turn <= 0 || cost.UTurn <= 0 {
+		return nil, fmt.Errorf("step costs have to be positive")
+	}
+	if err := Validate(g, start.Pos); err != nil {
+		return nil, fmt.Errorf("invalid start %s: %w", start, err)
+	}
+	if err := Validate(g, goal.Pos); err != nil {
+		return nil, fmt.Errorf("invalid goal %s: %w", goal, err)
+	}
+
+	type visit struct {
+		cost int
+		prev table.Pose
+		step Step
+	}
+	visited := map[table.Pose]visit{start: {}}
+	closed := map[table.Pose]bool{}
+
+	heuristic := func(p table.Pose) int {
+		return (abs(goal.Pos.X-p.Pos.X) + abs(goal.Pos.Y-p.Pos.Y)) * cost.Move
+	}
+
+	open := &queue{}
+	heap.Push(open, &item{pose: start, priority: heuristic(start)})
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(*item).pose
+		if closed[cur] {
+			continue
+		}
+		closed[cur] = true
+
+		if cur.Pos == goal.Pos && (goal.Facing == nil || cur.Facing == *goal.Facing) {
+			steps := []Step{}
+			for p := cur; p != start; p = visited[p].prev {
+				steps = append(steps, visited[p].step)
+			}
+			for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+				steps[i], steps[j] = steps[j], steps[i]
+			}
+			return steps, nil
+		}
+
+		for _, next := range neighbours(g, cur, cost) {
+			c := visited[cur].cost + next.cost
+			if v, ok := visited[next.pose]; ok && v.cost <= c {
+				continue
+			}
+			visited[next.pose] = visit{cost: c, prev: cur, step: next.step}
+			heap.Push(open, &item{pose: next.pose, priority: c + heuristic(next.pose)})
+		}
+	}
+	return nil, fmt.Errorf("%w: %s can not be reached from %s", ErrUnreachable, goal, start)
+}
+
+// Source returns the command file placing the robot at the start and
+// executing the steps, consecutive moves are merged into a single one
+func Source(start table.Pose, steps []Step) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PLACE %s\n", start)
+	for i := 0; i < len(steps); {
+		j := i + 1
+		for steps[i] == Move && j < len(steps) && steps[j] == Move {
+			j++
+		}
+
+		sb.WriteString(steps[i].String())
+		if j-i > 1 {
+			fmt.Fprintf(&sb, " %d", j-i)
+		}
+		sb.WriteString("\n")
+		i = j
+	}
+	return []byte(sb.String())
+}
+
+// edge is a step leading to the neighbouring pose
+type edge struct {
+	pose table.Pose
+	step Step
+	cost int
+}
+
+// neighbours returns poses reachable by a single step
+func neighbours(g Grid, p table.Pose, cost Cost) []edge {
+	edges := []edge{}
+
+	next := p.Facing.Step(p.Pos)
+	if Validate(g, next) == nil {
+		edges = append(edges, edge{pose: table.Pose{Pos: next, Facing: p.Facing}, step: Move, cost: cost.Move})
+	}
+
+	left, right := p.Facing, p.Facing
+	left.RotateLeft()
+	right.RotateRight()
+	edges = append(edges,
+		edge{pose: table.Pose{Pos: p.Pos, Facing: left}, step: Left, cost: cost.Turn},
+		edge{pose: table.Pose{Pos: p.Pos, Facing: right}, step: Right, cost: cost.Turn},
+		edge{pose: table.Pose{Pos: p.Pos, Facing: p.Facing.Opposite()}, step: UTurn, cost: cost.UTurn},
+	)
+	return edges
+}
+
+// Validate returns the error the table refuses the position with, nil when
+// the robot can be at it
+func Validate(g Grid, pos point.Point) error {
+	sizeX, sizeY := g.Size()
+	if pos.X < 0 || pos.Y < 0 || uint(pos.X) >= sizeX || uint(pos.Y) >= sizeY {
+		return table.ErrEndingPositionOutOfBounds
+	}
+	if g.Blocked(pos) {
+		return table.ErrPositionBlocked
+	}
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// item is a pose waiting in the queue
+type item struct {
+	pose     table.Pose
+	priority int
+	// seq orders items of the same priority by insertion so that plans are
+	// deterministic
+	seq int
+}
+
+// queue is a priority queue of poses ordered by the estimated cost
+type queue struct {
+	items []*item
+	seq   int
+}
+
+func (q *queue) Len() int {
+	return len(q.items)
+}
+
+func (q *queue) Less(i, j int) bool {
+	if q.items[i].priority != q.items[j].priority {
+		return q.items[i].priority < q.items[j].priority
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q *queue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *queue) Push(x interface{}) {
+	it := x.(*item)
+	it.seq = q.seq
+	q.seq++
+	q.items = append(q.items, it)
+}
+
+func (q *queue) Pop() interface{} {
+	it := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return it
+}
@@ -0,0 +1,103 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/plan"
+	"robot/internal/table"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		layout      string
+		start       string
+		goal        string
+		cost        plan.Cost
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "should plan path on empty table",
+			layout:   ".....\n.....\n.....\n.....\n.....\n",
+			start:    "0,4,SOUTH",
+			goal:     "3,0,NORTH",
+			cost:     plan.DefaultCost,
+			expected: "PLACE 0,4,SOUTH\nMOVE 4\nLEFT\nMOVE 3\nLEFT\n",
+		},
+		{
+			name:     "should plan path around blocked cells",
+			layout:   "...\n##.\n...\n",
+			start:    "0,0,EAST",
+			goal:     "0,2",
+			cost:     plan.DefaultCost,
+			expected: "PLACE 0,0,EAST\nMOVE 2\nLEFT\nMOVE 2\nLEFT\nMOVE 2\n",
+		},
+		{
+			name:     "should prefer turning around when it is cheaper",
+			layout:   "...\n",
+			start:    "1,0,EAST",
+			goal:     "0,0",
+			cost:     plan.Cost{Move: 1, Turn: 5, UTurn: 1},
+			expected: "PLACE 1,0,EAST\nUTURN\nMOVE\n",
+		},
+		{
+			name:     "should plan no steps when already at the goal",
+			layout:   "..\n",
+			start:    "1,0,EAST",
+			goal:     "1,0,EAST",
+			cost:     plan.DefaultCost,
+			expected: "PLACE 1,0,EAST\n",
+		},
+		{
+			name:        "should fail to plan path to walled off goal",
+			layout:      "..#.\n",
+			start:       "0,0,EAST",
+			goal:        "3,0",
+			cost:        plan.DefaultCost,
+			expectedErr: plan.ErrUnreachable,
+		},
+		{
+			name:        "should fail to plan path to blocked goal",
+			layout:      ".#\n",
+			start:       "0,0,EAST",
+			goal:        "1,0",
+			cost:        plan.DefaultCost,
+			expectedErr: table.ErrPositionBlocked,
+		},
+		{
+			name:        "should fail to plan path from outside of the table",
+			layout:      "..\n",
+			start:       "2,0,EAST",
+			goal:        "1,0",
+			cost:        plan.DefaultCost,
+			expectedErr: table.ErrEndingPositionOutOfBounds,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			layout, err := table.ParseLayout([]byte(tt.layout))
+			require.NoError(t, err)
+			start, err := table.ParsePose(tt.start)
+			require.NoError(t, err)
+			goal, err := plan.ParseGoal(tt.goal)
+			require.NoError(t, err)
+
+			steps, err := plan.Plan(layout, start, goal, tt.cost)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(plan.Source(start, steps)))
+		})
+	}
+}
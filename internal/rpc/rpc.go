@@ -0,0 +1,379 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/jsonrpc"
+	"robot/internal/point"
+	"robot/internal/session"
+	"robot/internal/table"
+)
+
+// Error codes of failed operations, they are in the range JSON-RPC 2.0 leaves
+// for implementation defined server errors
+const (
+	CodeTableNotFound          = -32001
+	CodeUninitializedPlacement = -32010
+	CodeOutOfBounds            = -32011
+	CodePositionBlocked        = -32012
+	// CodeCommandFailed is returned for commands that failed for any other
+	// reason than the table refusing them
+	CodeCommandFailed = -32020
+	// CodeStopped is returned for commands that were stopped by the step
+	// budget or the timeout before they finished
+	CodeStopped = -32021
+)
+
+const (
+	// DefaultMaxSteps is the number of commands a method may execute unless
+	// `WithMaxSteps` is given
+	DefaultMaxSteps = 1000000
+	// DefaultTimeout is the time a method may execute commands for unless
+	// `WithTimeout` is given
+	DefaultTimeout = 10 * time.Second
+)
+
+// Service drives tables created by the client over JSON-RPC 2.0, methods are
+// handled one at a time in the order they were received
+type Service struct {
+	registry *command.Registry
+	maxSteps int
+	timeout  time.Duration
+	tables   map[string]*session.Session
+	nextID   int
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Service)
+
+// WithRegistry provides an option to use custom command registry
+func WithRegistry(r *command.Registry) Option {
+	return func(s *Service) {
+		s.registry = r
+	}
+}
+
+// WithMaxSteps provides an option to limit the number of commands a single
+// method may execute, zero leaves methods unlimited
+func WithMaxSteps(n int) Option {
+	return func(s *Service) {
+		s.maxSteps = n
+	}
+}
+
+// WithTimeout provides an option to limit the time a single method may
+// execute commands for, zero leaves methods unlimited
+func WithTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = d
+	}
+}
+
+func New(opts ...Option) *Service {
+	s := &Service{
+		registry: command.DefaultRegistry,
+		maxSteps: DefaultMaxSteps,
+		timeout:  DefaultTimeout,
+		tables:   map[string]*session.Session{},
+		nextID:   1,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Serve handles messages read from the codec until the end of input
+func (s *Service) Serve(codec jsonrpc.Codec) error {
+	return jsonrpc.NewConn(codec).Serve(s.Handle)
+}
+
+// CreateParams are params of table.create, the layout overrides the size when
+// it is given
+type CreateParams struct {
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+	Layout string `json:"layout,omitempty"`
+}
+
+// TableParams are params of methods that only need the table
+type TableParams struct {
+	Table string `json:"table"`
+}
+
+// PlaceParams are params of robot.place
+type PlaceParams struct {
+	Table  string `json:"table"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Facing string `json:"facing"`
+}
+
+// MoveParams are params of robot.move, the robot moves by a single step when
+// Steps is zero
+type MoveParams struct {
+	Table   string `json:"table"`
+	Steps   int    `json:"steps,omitempty"`
+	Partial bool   `json:"partial,omitempty"`
+}
+
+// TurnParams are params of robot.turn
+type TurnParams struct {
+	Table   string `json:"table"`
+	Degrees int    `json:"degrees"`
+}
+
+// ExecParams are params of robot.exec
+type ExecParams struct {
+	Table   string `json:"table"`
+	Command string `json:"command"`
+}
+
+// ScriptParams are params of script.run
+type ScriptParams struct {
+	Table  string `json:"table"`
+	Script string `json:"script"`
+}
+
+// Report is the result of robot.report
+type Report struct {
+	Report string `json:"report"`
+}
+
+// Handle handles a single method call, robot methods return the state after
+// the operation and fail with the code of the refusal
+func (s *Service) Handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "table.create":
+		var p CreateParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.create(p)
+	case "table.list":
+		return s.list(), nil
+	case "table.delete":
+		var p TableParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		delete(s.tables, sess.ID())
+		return true, nil
+	case "robot.state":
+		var p TableParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		return sess.State(), nil
+	case "robot.place":
+		var p PlaceParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		facing, err := direction.Parse(p.Facing)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+		return do(sess, func(t *table.Table) error {
+			return t.PlaceRobot(point.Point{X: p.X, Y: p.Y}, facing)
+		})
+	case "robot.move":
+		var p MoveParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		return do(sess, func(t *table.Table) (err error) {
+			if p.Steps == 0 {
+				_, err = t.MoveRobot()
+			} else {
+				_, err = t.MoveRobotBy(p.Steps, p.Partial)
+			}
+			return err
+		})
+	case "robot.left", "robot.right":
+		var p TableParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		return do(sess, func(t *table.Table) error {
+			_, err := t.RotateRobot(method == "robot.left")
+			return err
+		})
+	case "robot.turn":
+		var p TurnParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		return do(sess, func(t *table.Table) error {
+			_, err := t.TurnRobot(p.Degrees)
+			return err
+		})
+	case "robot.report":
+		var p TableParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		res, err := sess.Do(func(t *table.Table) error {
+			return t.Report()
+		})
+		if err != nil {
+			return nil, toError(err)
+		}
+		return Report{Report: res.Output}, nil
+	case "robot.exec":
+		var p ExecParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		cmd, err := s.registry.Parse(p.Command)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+		ctx, cancel := s.context()
+		defer cancel()
+		res, err := sess.Exec(ctx, cmd, command.WithMaxSteps(s.maxSteps))
+		if err != nil {
+			return nil, toError(err)
+		}
+		return res, nil
+	case "script.run":
+		var p ScriptParams
+		sess, err := s.session(params, &p, &p.Table)
+		if err != nil {
+			return nil, err
+		}
+		return s.run(sess, p.Script)
+	}
+	return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+}
+
+func (s *Service) create(p CreateParams) (session.State, error) {
+	layout := table.Layout{SizeX: p.Width, SizeY: p.Height}
+	if p.Layout != "" {
+		var err error
+		if layout, err = table.ParseLayout([]byte(p.Layout)); err != nil {
+			return session.State{}, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("invalid layout: %s", err)}
+		}
+	}
+	if layout.SizeX == 0 || layout.SizeY == 0 {
+		return session.State{}, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "table width and height have to be positive"}
+	}
+
+	sess := session.New(strconv.Itoa(s.nextID), layout)
+	s.nextID++
+	s.tables[sess.ID()] = sess
+	return sess.State(), nil
+}
+
+// list returns states of every table ordered by their ids
+func (s *Service) list() []session.State {
+	states := make([]session.State, 0, len(s.tables))
+	for _, sess := range s.tables {
+		states = append(states, sess.State())
+	}
+	sort.Slice(states, func(i, j int) bool {
+		a, _ := strconv.Atoi(states[i].ID)
+		b, _ := strconv.Atoi(states[j].ID)
+		return a < b
+	})
+	return states
+}
+
+// session decodes the params and returns the table named by the id they hold
+func (s *Service) session(params json.RawMessage, p interface{}, id *string) (*session.Session, error) {
+	if err := unmarshalParams(params, p); err != nil {
+		return nil, err
+	}
+	sess, ok := s.tables[*id]
+	if !ok {
+		return nil, &jsonrpc.Error{Code: CodeTableNotFound, Message: fmt.Sprintf("table not found: '%s'", *id)}
+	}
+	return sess, nil
+}
+
+// run runs the script like a command file, refusals are ignored
+func (s *Service) run(sess *session.Session, script string) (session.Result, error) {
+	cmds, err := session.Scan(script, s.registry)
+	if err != nil {
+		return session.Result{}, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	res, err := sess.Run(ctx, cmds, command.WithMaxSteps(s.maxSteps))
+	if err != nil {
+		return session.Result{}, toError(err)
+	}
+	return res, nil
+}
+
+// context returns the context commands of a method are executed with, it is
+// done once the timeout passes
+func (s *Service) context() (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+// do performs the operation and returns the state after it or the error it
+// failed with
+func do(sess *session.Session, op func(t *table.Table) error) (interface{}, error) {
+	res, err := sess.Do(op)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return res.State, nil
+}
+
+// toError returns the JSON-RPC error with the code of the table refusal or of
+// the stopped run
+func toError(err error) *jsonrpc.Error {
+	code := CodeCommandFailed
+	var perr *command.PartialError
+	if errors.As(err, &perr) {
+		code = CodeStopped
+	} else if table.IsRefusal(err) {
+		switch {
+		case errors.Is(err, table.ErrUninitializedPlacement):
+			code = CodeUninitializedPlacement
+		case errors.Is(err, table.ErrEndingPositionOutOfBounds):
+			code = CodeOutOfBounds
+		default:
+			code = CodePositionBlocked
+		}
+	}
+	return &jsonrpc.Error{Code: code, Message: err.Error()}
+}
+
+// unmarshalParams decodes the params rejecting unknown fields
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
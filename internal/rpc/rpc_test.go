@@ -0,0 +1,129 @@
+package rpc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/jsonrpc"
+	"robot/internal/rpc"
+)
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		requests []string
+		expected []string
+	}{
+		{
+			name: "place move and report",
+			requests: []string{
+				`{"jsonrpc":"2.0","id":1,"method":"table.create","params":{"width":5,"height":5}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"robot.place","params":{"table":"1","x":1,"y":2,"facing":"EAST"}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"robot.move","params":{"table":"1","steps":2}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"robot.left","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":5,"method":"robot.report","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":6,"method":"robot.state","params":{"table":"1"}}`,
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":1,"result":{"id":"1","width":5,"height":5,"robot":null}}`,
+				`{"jsonrpc":"2.0","id":2,"result":{"id":"1","width":5,"height":5,"robot":{"x":1,"y":2,"facing":"EAST"}}}`,
+				`{"jsonrpc":"2.0","id":3,"result":{"id":"1","width":5,"height":5,"robot":{"x":3,"y":2,"facing":"EAST"}}}`,
+				`{"jsonrpc":"2.0","id":4,"result":{"id":"1","width":5,"height":5,"robot":{"x":3,"y":2,"facing":"NORTH"}}}`,
+				`{"jsonrpc":"2.0","id":5,"result":{"report":"Robot position: (3, 2) facing: NORTH\n"}}`,
+				`{"jsonrpc":"2.0","id":6,"result":{"id":"1","width":5,"height":5,"robot":{"x":3,"y":2,"facing":"NORTH"}}}`,
+			},
+		},
+		{
+			name: "refusals",
+			requests: []string{
+				`{"jsonrpc":"2.0","id":1,"method":"table.create","params":{"layout":"...\n.#.\n...\n"}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"robot.move","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"robot.place","params":{"table":"1","x":1,"y":1,"facing":"NORTH"}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"robot.place","params":{"table":"1","x":0,"y":1,"facing":"EAST"}}`,
+				`{"jsonrpc":"2.0","id":5,"method":"robot.move","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":6,"method":"robot.turn","params":{"table":"1","degrees":180}}`,
+				`{"jsonrpc":"2.0","id":7,"method":"robot.move","params":{"table":"1"}}`,
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":1,"result":{"id":"1","width":3,"height":3,"robot":null}}`,
+				`{"jsonrpc":"2.0","id":2,"error":{"code":-32010,"message":"uninitialized placement"}}`,
+				`{"jsonrpc":"2.0","id":3,"error":{"code":-32012,"message":"position blocked"}}`,
+				`{"jsonrpc":"2.0","id":4,"result":{"id":"1","width":3,"height":3,"robot":{"x":0,"y":1,"facing":"EAST"}}}`,
+				`{"jsonrpc":"2.0","id":5,"error":{"code":-32012,"message":"position blocked"}}`,
+				`{"jsonrpc":"2.0","id":6,"result":{"id":"1","width":3,"height":3,"robot":{"x":0,"y":1,"facing":"WEST"}}}`,
+				`{"jsonrpc":"2.0","id":7,"error":{"code":-32011,"message":"ending position out of bounds"}}`,
+			},
+		},
+		{
+			name: "commands and scripts",
+			requests: []string{
+				`{"jsonrpc":"2.0","id":1,"method":"table.create","params":{"width":5,"height":5}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"robot.exec","params":{"table":"1","command":"PLACE 0,0,NORTH"}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"script.run","params":{"table":"1","script":"MOVE\nRIGHT\nMOVE 3\nREPORT\n"}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"robot.exec","params":{"table":"1","command":"JUMP"}}`,
+				`{"jsonrpc":"2.0","id":5,"method":"script.run","params":{"table":"1","script":"INCLUDE \"other.txt\"\n"}}`,
+				`{"jsonrpc":"2.0","id":6,"method":"script.run","params":{"table":"1","script":"REPEAT 1000000000000\nEND\n"}}`,
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":1,"result":{"id":"1","width":5,"height":5,"robot":null}}`,
+				`{"jsonrpc":"2.0","id":2,"result":{"state":{"id":"1","width":5,"height":5,"robot":{"x":0,"y":0,"facing":"NORTH"}},"output":""}}`,
+				`{"jsonrpc":"2.0","id":3,"result":{"state":{"id":"1","width":5,"height":5,"robot":{"x":3,"y":1,"facing":"EAST"}},"output":"Robot position: (3, 1) facing: EAST\n"}}`,
+				`{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"invalid command detected: 'JUMP'"}}`,
+				`{"jsonrpc":"2.0","id":5,"error":{"code":-32602,"message":"script:1: failed opening file: INCLUDE is not supported in scripts"}}`,
+				`{"jsonrpc":"2.0","id":6,"error":{"code":-32021,"message":"stopped at script:1 after 1000000 steps with robot at 3,1,EAST: step budget exhausted"}}`,
+			},
+		},
+		{
+			name: "tables",
+			requests: []string{
+				`{"jsonrpc":"2.0","id":1,"method":"table.create","params":{"width":2,"height":3}}`,
+				`{"jsonrpc":"2.0","id":2,"method":"table.create","params":{"width":4,"height":4}}`,
+				`{"jsonrpc":"2.0","id":3,"method":"table.delete","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":4,"method":"table.list"}`,
+				`{"jsonrpc":"2.0","id":5,"method":"robot.state","params":{"table":"1"}}`,
+				`{"jsonrpc":"2.0","id":6,"method":"table.create","params":{"width":0,"height":3}}`,
+				`{"jsonrpc":"2.0","id":7,"method":"robot.state","params":{"table":"2","extra":true}}`,
+				`{"jsonrpc":"2.0","id":8,"method":"robot.jump"}`,
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":1,"result":{"id":"1","width":2,"height":3,"robot":null}}`,
+				`{"jsonrpc":"2.0","id":2,"result":{"id":"2","width":4,"height":4,"robot":null}}`,
+				`{"jsonrpc":"2.0","id":3,"result":true}`,
+				`{"jsonrpc":"2.0","id":4,"result":[{"id":"2","width":4,"height":4,"robot":null}]}`,
+				`{"jsonrpc":"2.0","id":5,"error":{"code":-32001,"message":"table not found: '1'"}}`,
+				`{"jsonrpc":"2.0","id":6,"error":{"code":-32602,"message":"table width and height have to be positive"}}`,
+				`{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"json: unknown field \"extra\""}}`,
+				`{"jsonrpc":"2.0","id":8,"error":{"code":-32601,"message":"method not found: robot.jump"}}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			in := strings.Join(tt.requests, "\n") + "\n"
+			err := rpc.New().Serve(jsonrpc.NewLineCodec(strings.NewReader(in), out))
+			require.NoError(t, err)
+
+			codec := jsonrpc.NewLineCodec(out, nil)
+			for _, exp := range tt.expected {
+				msg, err := codec.ReadMessage()
+				require.NoError(t, err)
+				actual, err := json.Marshal(msg)
+				require.NoError(t, err)
+				require.JSONEq(t, exp, string(actual))
+			}
+			_, err = codec.ReadMessage()
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package server
+
+import "errors"
+
+var (
+	ErrNotFound              error = errors.New("not found")
+	ErrMethodNotAllowed      error = errors.New("method not allowed")
+	ErrStreamingNotSupported error = errors.New("streaming not supported")
+	ErrBinaryMessage         error = errors.New("only text messages are supported")
+)
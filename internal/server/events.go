@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"robot/internal/session"
+	"robot/internal/table"
+)
+
+const (
+	// maxEvents limits the number of events kept for reconnecting clients
+	maxEvents = 1024
+	// keepAlive is the interval of comments keeping idle streams open
+	keepAlive = 15 * time.Second
+)
+
+// EventData is the data of an event sent to clients, the event name is the
+// kind of the table event
+type EventData struct {
+	// Op is the name of the table method, e.g. MoveRobot
+	Op string `json:"op"`
+	// Robot is the pose after the operation, nil when the robot is not placed
+	Robot *session.Robot `json:"robot"`
+	// Error is the reason of the refusal
+	Error string `json:"error,omitempty"`
+}
+
+// record is an event with its id
+type record struct {
+	id   int
+	kind string
+	data EventData
+}
+
+// eventLog keeps the latest events of a table and wakes up streams waiting
+// for new ones
+type eventLog struct {
+	mu      sync.Mutex
+	records []record
+	lastID  int
+	// changed is closed and replaced whenever an event is added
+	changed chan struct{}
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{changed: make(chan struct{})}
+}
+
+// add records the table event, it is used as the table event hook
+func (l *eventLog) add(e table.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastID++
+	rec := record{id: l.lastID, kind: e.Kind.String(), data: EventData{Op: e.Op}}
+	if e.Robot != nil {
+		rec.data.Robot = &session.Robot{X: e.Robot.Pos.X, Y: e.Robot.Pos.Y, Facing: e.Robot.Facing.String()}
+	}
+	if e.Err != nil {
+		rec.data.Error = e.Err.Error()
+	}
+
+	l.records = append(l.records, rec)
+	if len(l.records) > maxEvents {
+		l.records = l.records[len(l.records)-maxEvents:]
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// since returns the kept events following the id and the channel closed once
+// there are newer events. An id that was never issued, e.g. one of a table
+// lost by a restart, is unknown and every kept event is returned
+func (l *eventLog) since(id int) ([]record, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if id > l.lastID {
+		id = 0
+	}
+	i := len(l.records)
+	for i > 0 && l.records[i-1].id > id {
+		i--
+	}
+	return append([]record(nil), l.records[i:]...), l.changed
+}
+
+// last returns the id of the latest event
+func (l *eventLog) last() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastID
+}
+
+// events streams events of the table as Server-Sent Events until the client
+// disconnects or the table is deleted. Clients reconnecting with the
+// Last-Event-ID header first receive the events they missed as long as they
+// are still kept, other clients only receive new events. An id newer than the
+// latest event is unknown and every kept event is sent again
+func (s *Server) events(w http.ResponseWriter, r *http.Request, sess *tableSession) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, ErrStreamingNotSupported)
+		return
+	}
+
+	last := sess.events.last()
+	if h := r.Header.Get("Last-Event-ID"); h != "" {
+		id, err := strconv.Atoi(h)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID: '%s'", h))
+			return
+		}
+		last = id
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		records, changed := sess.events.since(last)
+		for _, rec := range records {
+			if err := writeEvent(w, rec); err != nil {
+				return
+			}
+			last = rec.id
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-sess.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes the record in the event stream format
+func writeEvent(w io.Writer, rec record) error {
+	data, err := json.Marshal(rec.data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", rec.id, rec.kind, data)
+	return err
+}
@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/server"
+)
+
+// post sends the JSON body to the test server and returns the status code
+func post(t *testing.T, url string, body interface{}) int {
+	raw, err := json.Marshal(body)
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// readEvent reads a single event from the stream skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" && len(lines) > 0 {
+			return strings.Join(lines, "\n")
+		}
+		if line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(server.New())
+	defer ts.Close()
+
+	require.Equal(t, http.StatusCreated, post(t, ts.URL+"/tables", server.CreateRequest{Width: 3, Height: 3}))
+	require.Equal(t, http.StatusOK, post(t, ts.URL+"/tables/1/scripts", server.ScriptRequest{Script: "MOVE\nPLACE 0,0,NORTH\nMOVE\nLEFT\nMOVE\n"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/tables/1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// events the client missed are sent first
+	stream := bufio.NewReader(resp.Body)
+	require.Equal(t, "id: 3\nevent: moved\ndata: {\"op\":\"MoveRobot\",\"robot\":{\"x\":0,\"y\":1,\"facing\":\"NORTH\"}}", readEvent(t, stream))
+	require.Equal(t, "id: 4\nevent: rotated\ndata: {\"op\":\"RotateRobot\",\"robot\":{\"x\":0,\"y\":1,\"facing\":\"WEST\"}}", readEvent(t, stream))
+	require.Equal(t, "id: 5\nevent: refused\ndata: {\"op\":\"MoveRobot\",\"robot\":{\"x\":0,\"y\":1,\"facing\":\"WEST\"},\"error\":\"ending position out of bounds\"}", readEvent(t, stream))
+
+	require.Equal(t, http.StatusOK, post(t, ts.URL+"/tables/1/commands", server.CommandRequest{Command: "REPORT"}))
+	require.Equal(t, "id: 6\nevent: reported\ndata: {\"op\":\"Report\",\"robot\":{\"x\":0,\"y\":1,\"facing\":\"WEST\"}}", readEvent(t, stream))
+
+	// deleting the table ends the stream
+	del, err := http.NewRequest(http.MethodDelete, ts.URL+"/tables/1", nil)
+	require.NoError(t, err)
+	delResp, err := http.DefaultClient.Do(del)
+	require.NoError(t, err)
+	delResp.Body.Close()
+	_, err = io.ReadAll(stream)
+	require.NoError(t, err)
+}
+
+func TestEventsInvalidLastEventID(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(server.New())
+	defer ts.Close()
+	require.Equal(t, http.StatusCreated, post(t, ts.URL+"/tables", server.CreateRequest{Width: 3, Height: 3}))
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/tables/1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "latest")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestEventsUnknownLastEventID(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(server.New())
+	defer ts.Close()
+	require.Equal(t, http.StatusCreated, post(t, ts.URL+"/tables", server.CreateRequest{Width: 3, Height: 3}))
+	require.Equal(t, http.StatusOK, post(t, ts.URL+"/tables/1/commands", server.CommandRequest{Command: "PLACE 0,0,NORTH"}))
+
+	// the id of a table lost by a restart replays the events from the start
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/tables/1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "42")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stream := bufio.NewReader(resp.Body)
+	require.Equal(t, "id: 1\nevent: placed\ndata: {\"op\":\"PlaceRobot\",\"robot\":{\"x\":0,\"y\":0,\"facing\":\"NORTH\"}}", readEvent(t, stream))
+	require.Equal(t, http.StatusOK, post(t, ts.URL+"/tables/1/commands", server.CommandRequest{Command: "LEFT"}))
+	require.Equal(t, "id: 2\nevent: rotated\ndata: {\"op\":\"RotateRobot\",\"robot\":{\"x\":0,\"y\":0,\"facing\":\"WEST\"}}", readEvent(t, stream))
+}
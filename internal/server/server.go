@@ -0,0 +1,424 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"robot/internal/command"
+	"robot/internal/session"
+	"robot/internal/table"
+)
+
+// maxBodySize limits the size of request bodies
+const maxBodySize = 1 << 20
+
+const (
+	// DefaultMaxSteps is the number of commands a request may execute unless
+	// `WithMaxSteps` is given
+	DefaultMaxSteps = 1000000
+	// DefaultTimeout is the time a request may execute commands for unless
+	// `WithTimeout` is given
+	DefaultTimeout = 10 * time.Second
+)
+
+// Server exposes tables over HTTP, every table is a session with its own
+// `table.Table` that lives until it is deleted
+type Server struct {
+	registry *command.Registry
+	maxSteps int
+	timeout  time.Duration
+	// origins are pages of other hosts allowed to open WebSocket connections
+	origins []string
+
+	mu       sync.Mutex
+	sessions map[string]*tableSession
+	nextID   int
+}
+
+// tableSession is a table created by a client with the log of its events
+type tableSession struct {
+	*session.Session
+	events *eventLog
+	// done is closed once the table is deleted
+	done chan struct{}
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Server)
+
+// WithRegistry provides an option to use custom command registry
+func WithRegistry(r *command.Registry) Option {
+	return func(s *Server) {
+		s.registry = r
+	}
+}
+
+// WithMaxSteps provides an option to limit the number of commands a single
+// request may execute, zero leaves requests unlimited
+func WithMaxSteps(n int) Option {
+	return func(s *Server) {
+		s.maxSteps = n
+	}
+}
+
+// WithTimeout provides an option to limit the time a single request may
+// execute commands for including the time it waits for the table, zero leaves
+// requests unlimited
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithOrigins provides an option to allow pages of the origins, e.g.
+// https://example.com, to open WebSocket connections, pages served by the host
+// of the request are always allowed and "*" allows every page
+func WithOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.origins = append(s.origins, origins...)
+	}
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		registry: command.DefaultRegistry,
+		maxSteps: DefaultMaxSteps,
+		timeout:  DefaultTimeout,
+		sessions: map[string]*tableSession{},
+		nextID:   1,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateRequest is the body of POST /tables, the layout overrides the size
+// when it is given
+type CreateRequest struct {
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+	Layout string `json:"layout,omitempty"`
+}
+
+// CommandRequest is the body of POST /tables/{id}/commands
+type CommandRequest struct {
+	Command string `json:"command"`
+}
+
+// ScriptRequest is the body of POST /tables/{id}/scripts
+type ScriptRequest struct {
+	Script string `json:"script"`
+}
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Partial is set for runs stopped by the step budget or the timeout
+	Partial *PartialResult `json:"partial,omitempty"`
+}
+
+// PartialResult is the table of a run stopped before it finished, commands
+// executed before it was stopped are not undone
+type PartialResult struct {
+	// Steps is the number of commands executed before the run was stopped
+	Steps int           `json:"steps"`
+	State session.State `json:"state"`
+}
+
+// ServeHTTP routes requests to the table endpoints:
+//
+//	GET    /tables               lists tables
+//	POST   /tables               creates a table
+//	GET    /tables/{id}          returns the table state
+//	DELETE /tables/{id}          deletes the table
+//	POST   /tables/{id}/commands executes a single command
+//	POST   /tables/{id}/scripts  runs a command file
+//	GET    /tables/{id}/report   returns the REPORT line
+//	GET    /tables/{id}/events   streams table events as Server-Sent Events
+//	GET    /tables/{id}/socket   controls the robot over WebSocket
+//
+// Failed requests answer an `ErrorResponse` with the status telling why:
+//
+//	400 the request or a command of it is invalid
+//	404 the table does not exist
+//	409 the robot is not placed
+//	422 the table refused a command
+//	503 the run exhausted the step budget or the timeout, the response holds
+//	    the partial result
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "tables" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", ErrNotFound, r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w)
+		case http.MethodPost:
+			s.create(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	sess, ok := s.session(parts[1])
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: table %s", ErrNotFound, parts[1]))
+		return
+	}
+
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, sess.State())
+		case http.MethodDelete:
+			s.delete(sess.ID())
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+		return
+	}
+
+	switch parts[2] {
+	case "commands":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.exec(w, r, sess)
+	case "scripts":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.run(w, r, sess)
+	case "report":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		report(w, sess)
+	case "events":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.events(w, r, sess)
+	case "socket":
+		s.socket(w, r, sess)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", ErrNotFound, r.URL.Path))
+	}
+}
+
+// list writes states of every table ordered by their ids
+func (s *Server) list(w http.ResponseWriter) {
+	s.mu.Lock()
+	sessions := make([]*tableSession, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		a, _ := strconv.Atoi(sessions[i].ID())
+		b, _ := strconv.Atoi(sessions[j].ID())
+		return a < b
+	})
+	states := make([]session.State, 0, len(sessions))
+	for _, sess := range sessions {
+		states = append(states, sess.State())
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	layout := table.Layout{SizeX: req.Width, SizeY: req.Height}
+	if req.Layout != "" {
+		var err error
+		if layout, err = table.ParseLayout([]byte(req.Layout)); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid layout: %w", err))
+			return
+		}
+	}
+	if layout.SizeX == 0 || layout.SizeY == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("table width and height have to be positive"))
+		return
+	}
+
+	sess := &tableSession{
+		events: newEventLog(),
+		done:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	sess.Session = session.New(strconv.Itoa(s.nextID), layout, session.WithEventHook(sess.events.add))
+	s.nextID++
+	s.sessions[sess.ID()] = sess
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/tables/"+sess.ID())
+	writeJSON(w, http.StatusCreated, sess.State())
+}
+
+func (s *Server) session(id string) (*tableSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+// delete deletes the table and ends its event streams
+func (s *Server) delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		close(sess.done)
+		delete(s.sessions, id)
+	}
+}
+
+// exec executes a single command, unlike in scripts refusals fail the request
+func (s *Server) exec(w http.ResponseWriter, r *http.Request, sess *tableSession) {
+	var req CommandRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	cmd, err := s.registry.Parse(req.Command)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := s.context(r)
+	defer cancel()
+	res, err := sess.Exec(ctx, cmd, command.WithMaxSteps(s.maxSteps))
+	if err != nil {
+		writeFailure(w, res, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// run runs the script like a command file, refusals are ignored and INCLUDE
+// is not supported as scripts have no files to include. The run is stopped
+// once the client goes away or it exceeds the step budget or the timeout
+func (s *Server) run(w http.ResponseWriter, r *http.Request, sess *tableSession) {
+	var req ScriptRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	cmds, err := session.Scan(req.Script, s.registry)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := s.context(r)
+	defer cancel()
+	res, err := sess.Run(ctx, cmds, command.WithMaxSteps(s.maxSteps))
+	if err != nil {
+		writeFailure(w, res, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// context returns the context commands of the request are executed with, it
+// is done once the client goes away or the timeout passes
+func (s *Server) context(r *http.Request) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), s.timeout)
+}
+
+// report writes the REPORT line of the table as plain text
+func report(w http.ResponseWriter, sess *tableSession) {
+	res, err := sess.Do(func(t *table.Table) error {
+		return t.Report()
+	})
+	if err != nil {
+		writeError(w, status(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, res.Output)
+}
+
+// status returns the HTTP status code of the error a command failed with,
+// refusals of the table are told apart from commands that can not be executed
+// as requested and from runs that were stopped before they finished
+func status(err error) int {
+	switch {
+	case errors.Is(err, command.ErrMaxSteps), errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, table.ErrUninitializedPlacement):
+		return http.StatusConflict
+	case table.IsRefusal(err):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// decode decodes the JSON body of the request rejecting unknown fields
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ErrorResponse{Error: err.Error()})
+}
+
+// writeFailure writes the error commands failed with, runs stopped before
+// they finished carry the table they left
+func writeFailure(w http.ResponseWriter, res session.Result, err error) {
+	resp := ErrorResponse{Error: err.Error()}
+	var perr *command.PartialError
+	if errors.As(err, &perr) {
+		resp.Partial = &PartialResult{Steps: perr.Steps, State: res.State}
+	}
+	writeJSON(w, status(err), resp)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+}
@@ -0,0 +1,217 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/server"
+	"robot/internal/session"
+)
+
+// do sends the request to the handler and returns the status code and body
+func do(t *testing.T, h http.Handler, method, path string, body interface{}) (int, string) {
+	var payload string
+	if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(t, err)
+		payload = string(raw)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(payload)))
+	return rec.Code, rec.Body.String()
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	srv := server.New()
+
+	code, body := do(t, srv, http.MethodPost, "/tables", server.CreateRequest{Width: 5, Height: 5})
+	require.Equal(t, http.StatusCreated, code)
+	require.JSONEq(t, `{"id":"1","width":5,"height":5,"robot":null}`, body)
+
+	code, body = do(t, srv, http.MethodPost, "/tables/1/commands", server.CommandRequest{Command: "PLACE 1,2,EAST"})
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"state":{"id":"1","width":5,"height":5,"robot":{"x":1,"y":2,"facing":"EAST"}},"output":""}`, body)
+
+	code, body = do(t, srv, http.MethodPost, "/tables/1/scripts", server.ScriptRequest{Script: "MOVE 2\nREPORT\nMOVE\nLEFT\nREPORT\n"})
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"state":{"id":"1","width":5,"height":5,"robot":{"x":4,"y":2,"facing":"NORTH"}},"output":"Robot position: (3, 2) facing: EAST\nRobot position: (4, 2) facing: NORTH\n"}`, body)
+
+	code, body = do(t, srv, http.MethodGet, "/tables/1", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"id":"1","width":5,"height":5,"robot":{"x":4,"y":2,"facing":"NORTH"}}`, body)
+
+	code, body = do(t, srv, http.MethodGet, "/tables/1/report", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "Robot position: (4, 2) facing: NORTH\n", body)
+
+	code, _ = do(t, srv, http.MethodPost, "/tables", server.CreateRequest{Layout: ".#\n..\n"})
+	require.Equal(t, http.StatusCreated, code)
+
+	code, body = do(t, srv, http.MethodGet, "/tables", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `[{"id":"1","width":5,"height":5,"robot":{"x":4,"y":2,"facing":"NORTH"}},{"id":"2","width":2,"height":2,"robot":null}]`, body)
+
+	code, _ = do(t, srv, http.MethodDelete, "/tables/1", nil)
+	require.Equal(t, http.StatusNoContent, code)
+	code, _ = do(t, srv, http.MethodGet, "/tables/1", nil)
+	require.Equal(t, http.StatusNotFound, code)
+}
+
+func TestServerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name          string
+		method        string
+		path          string
+		body          interface{}
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:          "should refuse to move robot that is not placed",
+			method:        http.MethodPost,
+			path:          "/tables/1/commands",
+			body:          server.CommandRequest{Command: "MOVE"},
+			expectedCode:  http.StatusConflict,
+			expectedError: "uninitialized placement",
+		},
+		{
+			name:          "should refuse to report robot that is not placed",
+			method:        http.MethodGet,
+			path:          "/tables/1/report",
+			expectedCode:  http.StatusConflict,
+			expectedError: "uninitialized placement",
+		},
+		{
+			name:          "should refuse to place robot outside of the table",
+			method:        http.MethodPost,
+			path:          "/tables/1/commands",
+			body:          server.CommandRequest{Command: "PLACE 5,0,NORTH"},
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedError: "ending position out of bounds",
+		},
+		{
+			name:          "should refuse to place robot on blocked cell",
+			method:        http.MethodPost,
+			path:          "/tables/1/commands",
+			body:          server.CommandRequest{Command: "PLACE 1,1,NORTH"},
+			expectedCode:  http.StatusUnprocessableEntity,
+			expectedError: "position blocked",
+		},
+		{
+			name:          "should reject invalid command",
+			method:        http.MethodPost,
+			path:          "/tables/1/commands",
+			body:          server.CommandRequest{Command: "JUMP"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid command detected: 'JUMP'",
+		},
+		{
+			name:          "should reject command that can not be executed",
+			method:        http.MethodPost,
+			path:          "/tables/1/scripts",
+			body:          server.ScriptRequest{Script: "PLACE 0,0,EAST\nGOTO 2,2\n"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "script:2: goal is unreachable: 2,2 can not be reached from 0,0,EAST",
+		},
+		{
+			name:          "should reject script including files",
+			method:        http.MethodPost,
+			path:          "/tables/1/scripts",
+			body:          server.ScriptRequest{Script: "INCLUDE \"/etc/passwd\"\n"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "script:1: failed opening file: INCLUDE is not supported in scripts",
+		},
+		{
+			name:          "should stop script exhausting the step budget",
+			method:        http.MethodPost,
+			path:          "/tables/1/scripts",
+			body:          server.ScriptRequest{Script: "REPEAT 1000000000000\nEND\n"},
+			expectedCode:  http.StatusServiceUnavailable,
+			expectedError: "stopped at script:1 after 1000000 steps with robot not placed: step budget exhausted",
+		},
+		{
+			name:          "should reject unknown fields",
+			method:        http.MethodPost,
+			path:          "/tables/1/commands",
+			body:          map[string]string{"cmd": "MOVE"},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid request body: json: unknown field \"cmd\"",
+		},
+		{
+			name:          "should reject table without size",
+			method:        http.MethodPost,
+			path:          "/tables",
+			body:          server.CreateRequest{},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "table width and height have to be positive",
+		},
+		{
+			name:          "should not find unknown table",
+			method:        http.MethodGet,
+			path:          "/tables/7",
+			expectedCode:  http.StatusNotFound,
+			expectedError: "not found: table 7",
+		},
+		{
+			name:          "should not allow unsupported method",
+			method:        http.MethodPut,
+			path:          "/tables/1",
+			expectedCode:  http.StatusMethodNotAllowed,
+			expectedError: "method not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := server.New()
+			// ...
+			// ###
+			// ...
+			code, _ := do(t, srv, http.MethodPost, "/tables", server.CreateRequest{Layout: "...\n###\n...\n"})
+			require.Equal(t, http.StatusCreated, code)
+
+			code, body := do(t, srv, tt.method, tt.path, tt.body)
+			require.Equal(t, tt.expectedCode, code)
+
+			var resp server.ErrorResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &resp))
+			require.Equal(t, tt.expectedError, resp.Error)
+		})
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	t.Parallel()
+
+	srv := server.New(server.WithMaxSteps(0), server.WithTimeout(20*time.Millisecond))
+	code, _ := do(t, srv, http.MethodPost, "/tables", server.CreateRequest{Width: 5, Height: 5})
+	require.Equal(t, http.StatusCreated, code)
+
+	code, body := do(t, srv, http.MethodPost, "/tables/1/scripts", server.ScriptRequest{Script: "PLACE 0,0,NORTH\nREPEAT 1000000000000\nEND\n"})
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	var resp server.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	require.Contains(t, resp.Error, "context deadline exceeded")
+	require.NotNil(t, resp.Partial)
+	require.Greater(t, resp.Partial.Steps, 1)
+	require.Equal(t, &session.Robot{X: 0, Y: 0, Facing: "NORTH"}, resp.Partial.State.Robot)
+
+	// the table is released once the script was stopped
+	code, body = do(t, srv, http.MethodPost, "/tables/1/commands", server.CommandRequest{Command: "MOVE"})
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"state":{"id":"1","width":5,"height":5,"robot":{"x":0,"y":1,"facing":"NORTH"}},"output":""}`, body)
+}
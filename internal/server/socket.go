@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"robot/internal/command"
+	"robot/internal/session"
+	"robot/internal/table"
+	"robot/internal/websocket"
+)
+
+// SocketResponse is sent in reply to every command received over WebSocket,
+// Status is applied, refused or error
+type SocketResponse struct {
+	Type string `json:"type"`
+	// Seq is the 1-based index of the command received over the connection
+	Seq     int            `json:"seq"`
+	Command string         `json:"command"`
+	Status  string         `json:"status"`
+	Robot   *session.Robot `json:"robot"`
+	Output  string         `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// SocketEvent is sent over WebSocket for every event of the table, including
+// events caused by other clients
+type SocketEvent struct {
+	Type  string    `json:"type"`
+	ID    int       `json:"id"`
+	Event string    `json:"event"`
+	Data  EventData `json:"data"`
+}
+
+// socket upgrades the request to WebSocket, every text message is a single
+// command answered by a response while events of the table are sent as they
+// happen. The connection is closed once the table is deleted
+func (s *Server) socket(w http.ResponseWriter, r *http.Request, sess *tableSession) {
+	conn, err := websocket.Upgrade(w, r, websocket.WithOrigins(s.origins...))
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.CloseNormal, "")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go pumpEvents(conn, sess, sess.events.last(), stop)
+
+	for seq := 1; ; seq++ {
+		typ, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		resp := SocketResponse{Type: "response", Seq: seq, Command: string(msg)}
+		if typ != websocket.TextMessage {
+			resp.Status, resp.Error = "error", ErrBinaryMessage.Error()
+		} else {
+			s.socketExec(r, sess, &resp)
+		}
+		if err := writeMessage(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+// socketExec executes the command of the response and fills in its outcome
+func (s *Server) socketExec(r *http.Request, sess *tableSession, resp *SocketResponse) {
+	cmd, err := s.registry.Parse(resp.Command)
+	if err != nil {
+		resp.Status, resp.Error = "error", err.Error()
+		return
+	}
+
+	ctx, cancel := s.context(r)
+	defer cancel()
+	res, err := sess.Exec(ctx, cmd, command.WithMaxSteps(s.maxSteps))
+	resp.Robot, resp.Output = res.State.Robot, res.Output
+	switch {
+	case err == nil:
+		resp.Status = "applied"
+	case table.IsRefusal(err):
+		resp.Status, resp.Error = "refused", err.Error()
+	default:
+		resp.Status, resp.Error = "error", err.Error()
+	}
+}
+
+// pumpEvents sends events following the id until stopped or until the table
+// is deleted
+func pumpEvents(conn *websocket.Conn, sess *tableSession, last int, stop <-chan struct{}) {
+	for {
+		records, changed := sess.events.since(last)
+		for _, rec := range records {
+			if err := writeMessage(conn, SocketEvent{Type: "event", ID: rec.id, Event: rec.kind, Data: rec.data}); err != nil {
+				return
+			}
+			last = rec.id
+		}
+
+		select {
+		case <-changed:
+		case <-sess.done:
+			conn.Close(websocket.CloseGoingAway, "table deleted")
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+func writeMessage(conn *websocket.Conn, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/server"
+	"robot/internal/session"
+	"robot/internal/websocket"
+)
+
+func TestSocket(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(server.New())
+	defer ts.Close()
+	require.Equal(t, http.StatusCreated, post(t, ts.URL+"/tables", server.CreateRequest{Width: 3, Height: 3}))
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(ts.URL, "http") + "/tables/1/socket")
+	require.NoError(t, err)
+
+	for _, cmd := range []string{"PLACE 0,0,NORTH", "MOVE 5", "JUMP", "REPORT"} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(cmd)))
+	}
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("MOVE")))
+
+	// responses and events are interleaved, they are told apart by their type
+	var responses []server.SocketResponse
+	var events []server.SocketEvent
+	for len(responses) < 5 || len(events) < 3 {
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		var kind struct{ Type string }
+		require.NoError(t, json.Unmarshal(msg, &kind))
+		switch kind.Type {
+		case "response":
+			var resp server.SocketResponse
+			require.NoError(t, json.Unmarshal(msg, &resp))
+			responses = append(responses, resp)
+		case "event":
+			var e server.SocketEvent
+			require.NoError(t, json.Unmarshal(msg, &e))
+			events = append(events, e)
+		}
+	}
+
+	robot := &session.Robot{X: 0, Y: 0, Facing: "NORTH"}
+	require.Equal(t, []server.SocketResponse{
+		{Type: "response", Seq: 1, Command: "PLACE 0,0,NORTH", Status: "applied", Robot: robot},
+		{Type: "response", Seq: 2, Command: "MOVE 5", Status: "refused", Robot: robot, Error: "ending position out of bounds"},
+		{Type: "response", Seq: 3, Command: "JUMP", Status: "error", Error: "invalid command detected: 'JUMP'"},
+		{Type: "response", Seq: 4, Command: "REPORT", Status: "applied", Robot: robot, Output: "Robot position: (0, 0) facing: NORTH\n"},
+		{Type: "response", Seq: 5, Command: "MOVE", Status: "error", Error: "only text messages are supported"},
+	}, responses)
+	require.Equal(t, []server.SocketEvent{
+		{Type: "event", ID: 1, Event: "placed", Data: server.EventData{Op: "PlaceRobot", Robot: robot}},
+		{Type: "event", ID: 2, Event: "refused", Data: server.EventData{Op: "MoveRobotBy", Robot: robot, Error: "ending position out of bounds"}},
+		{Type: "event", ID: 3, Event: "reported", Data: server.EventData{Op: "Report", Robot: robot}},
+	}, events)
+
+	// changes made by other clients are sent as events
+	require.Equal(t, http.StatusOK, post(t, ts.URL+"/tables/1/commands", server.CommandRequest{Command: "RIGHT"}))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"event","id":4,"event":"rotated","data":{"op":"RotateRobot","robot":{"x":0,"y":0,"facing":"EAST"}}}`, string(msg))
+
+	del, err := http.NewRequest(http.MethodDelete, ts.URL+"/tables/1", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(del)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	require.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	require.Equal(t, "table deleted", closeErr.Reason)
+}
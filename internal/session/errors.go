@@ -0,0 +1,7 @@
+package session
+
+import "errors"
+
+var (
+	ErrIncludeNotSupported error = errors.New("INCLUDE is not supported in scripts")
+)
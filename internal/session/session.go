@@ -0,0 +1,171 @@
+package session
+
+import (
+	"bytes"
+	"context"
+
+	"robot/internal/command"
+	"robot/internal/table"
+)
+
+// Robot is the pose of the robot
+type Robot struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Facing string `json:"facing"`
+}
+
+// State is the state of a table, Robot is nil when the robot is not placed
+type State struct {
+	ID     string `json:"id"`
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+	Robot  *Robot `json:"robot"`
+}
+
+// Result is the outcome of commands and scripts, Output holds the REPORT
+// lines they wrote
+type Result struct {
+	State  State  `json:"state"`
+	Output string `json:"output"`
+}
+
+// Session is a table driven by remote clients, operations on it are performed
+// one at a time
+type Session struct {
+	id     string
+	sizeX  uint
+	sizeY  uint
+	table  *table.Table
+	output *bytes.Buffer
+	opts   []table.Option
+	// sem is held while an operation is performed, unlike a mutex it can be
+	// given up waiting for once the context of the operation is done
+	sem chan struct{}
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Session)
+
+// WithEventHook provides an option to observe operations on the table
+func WithEventHook(hook table.EventHook) Option {
+	return func(s *Session) {
+		s.opts = append(s.opts, table.WithEventHook(hook))
+	}
+}
+
+// New returns a session with an unplaced robot on a table of the layout
+func New(id string, layout table.Layout, opts ...Option) *Session {
+	s := &Session{
+		id:     id,
+		sizeX:  layout.SizeX,
+		sizeY:  layout.SizeY,
+		output: &bytes.Buffer{},
+		sem:    make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.table = table.New(layout.SizeX, layout.SizeY, append([]table.Option{
+		table.WithReportOutput(s.output),
+		table.WithBlocked(layout.BlockedCells...),
+	}, s.opts...)...)
+	return s
+}
+
+// ID returns the id the session was created with
+func (s *Session) ID() string {
+	return s.id
+}
+
+// State returns the state of the table
+func (s *Session) State() State {
+	s.lock(context.Background())
+	defer s.unlock()
+	return s.state()
+}
+
+// Do performs the operation on the table and returns the state after it, the
+// output holds the REPORT lines it wrote
+func (s *Session) Do(op func(t *table.Table) error) (Result, error) {
+	return s.do(context.Background(), op)
+}
+
+// do performs the operation once the session is free, it fails without
+// performing it when the context is done first
+func (s *Session) do(ctx context.Context, op func(t *table.Table) error) (Result, error) {
+	if err := s.lock(ctx); err != nil {
+		return Result{}, err
+	}
+	defer s.unlock()
+	defer s.output.Reset()
+
+	if err := op(s.table); err != nil {
+		return Result{State: s.state()}, err
+	}
+	return Result{State: s.state(), Output: s.output.String()}, nil
+}
+
+// Exec executes a single command, unlike in scripts refusals are returned
+func (s *Session) Exec(ctx context.Context, cmd command.Command, opts ...command.RunOption) (Result, error) {
+	var refusal error
+	checked := func(t command.Table, env *command.Env) error {
+		err := cmd(t, env)
+		if table.IsRefusal(err) {
+			refusal = err
+		}
+		return err
+	}
+
+	res, err := s.Run(ctx, []command.Command{checked}, opts...)
+	if err == nil && refusal != nil {
+		return res, refusal
+	}
+	return res, err
+}
+
+// Run runs the commands like a command file, refusals are ignored and HALT
+// stops the run without an error. The run holds the session until it
+// finishes or the context is done, see `command.RunContext`
+func (s *Session) Run(ctx context.Context, cmds []command.Command, opts ...command.RunOption) (Result, error) {
+	return s.do(ctx, func(t *table.Table) error {
+		return command.RunContext(ctx, t, cmds, opts...)
+	})
+}
+
+// lock waits until the session is free or the context is done
+func (s *Session) lock(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Session) unlock() {
+	<-s.sem
+}
+
+// state returns the state of the table, the session has to be locked
+func (s *Session) state() State {
+	st := State{ID: s.id, Width: s.sizeX, Height: s.sizeY}
+	if pos, facing, err := s.table.Robot(); err == nil {
+		st.Robot = &Robot{X: pos.X, Y: pos.Y, Facing: facing.String()}
+	}
+	return st
+}
+
+// Scan scans the script like a command file, INCLUDE is not supported as
+// scripts have no files to include
+func Scan(script string, registry *command.Registry) ([]command.Command, error) {
+	const scriptName = "script"
+	return command.ScanCommandList(scriptName, command.WithRegistry(registry), command.WithReadFile(func(fileName string) ([]byte, error) {
+		if fileName != scriptName {
+			return nil, ErrIncludeNotSupported
+		}
+		return []byte(script), nil
+	}))
+}
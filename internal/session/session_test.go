@@ -0,0 +1,116 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/session"
+	"robot/internal/table"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		script         string
+		expectedOutput string
+		expectedState  session.State
+		expectedErr    error
+	}{
+		{
+			name:           "should run script ignoring refusals",
+			script:         "MOVE\nPLACE 0,0,NORTH\nMOVE 9\nMOVE\nREPORT\n",
+			expectedOutput: "Robot position: (0, 1) facing: NORTH\n",
+			expectedState:  session.State{ID: "1", Width: 5, Height: 5, Robot: &session.Robot{X: 0, Y: 1, Facing: "NORTH"}},
+		},
+		{
+			name:          "should stop script at halt",
+			script:        "PLACE 1,1,EAST\nHALT\nMOVE\n",
+			expectedState: session.State{ID: "1", Width: 5, Height: 5, Robot: &session.Robot{X: 1, Y: 1, Facing: "EAST"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sess := session.New("1", table.Layout{SizeX: 5, SizeY: 5})
+			cmds, err := session.Scan(tt.script, command.DefaultRegistry)
+			require.NoError(t, err)
+
+			res, err := sess.Run(context.Background(), cmds)
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.Equal(t, tt.expectedOutput, res.Output)
+			require.Equal(t, tt.expectedState, res.State)
+			require.Equal(t, tt.expectedState, sess.State())
+		})
+	}
+}
+
+func TestSessionExec(t *testing.T) {
+	t.Parallel()
+
+	sess := session.New("1", table.Layout{SizeX: 5, SizeY: 5})
+	parse := func(src string) command.Command {
+		cmd, err := command.DefaultRegistry.Parse(src)
+		require.NoError(t, err)
+		return cmd
+	}
+
+	_, err := sess.Exec(context.Background(), parse("MOVE"))
+	require.ErrorIs(t, err, table.ErrUninitializedPlacement)
+
+	_, err = sess.Exec(context.Background(), parse("PLACE 4,4,NORTH"))
+	require.NoError(t, err)
+
+	res, err := sess.Exec(context.Background(), parse("MOVE"))
+	require.ErrorIs(t, err, table.ErrEndingPositionOutOfBounds)
+	require.Equal(t, &session.Robot{X: 4, Y: 4, Facing: "NORTH"}, res.State.Robot)
+
+	res, err = sess.Exec(context.Background(), parse("REPORT"))
+	require.NoError(t, err)
+	require.Equal(t, "Robot position: (4, 4) facing: NORTH\n", res.Output)
+}
+
+func TestScanInclude(t *testing.T) {
+	t.Parallel()
+
+	_, err := session.Scan("INCLUDE \"other.txt\"\n", command.DefaultRegistry)
+	require.EqualError(t, err, "script:1: failed opening file: "+session.ErrIncludeNotSupported.Error())
+}
+
+func TestSessionCancel(t *testing.T) {
+	t.Parallel()
+
+	sess := session.New("1", table.Layout{SizeX: 5, SizeY: 5})
+	cmds, err := session.Scan("PLACE 0,0,NORTH\nREPEAT 1000000000000\nEND\n", command.DefaultRegistry)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := sess.Run(ctx, cmds)
+		done <- err
+	}()
+
+	// operations waiting for the session give up once their context is done
+	require.Eventually(t, func() bool {
+		waiting, stop := context.WithTimeout(context.Background(), time.Millisecond)
+		defer stop()
+		_, err := sess.Run(waiting, nil)
+		return errors.Is(err, context.DeadlineExceeded)
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	var perr *command.PartialError
+	require.ErrorAs(t, <-done, &perr)
+	require.ErrorIs(t, perr, context.Canceled)
+	require.Equal(t, &session.Robot{X: 0, Y: 0, Facing: "NORTH"}, sess.State().Robot)
+}
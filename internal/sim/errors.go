@@ -0,0 +1,9 @@
+package sim
+
+import "errors"
+
+var (
+	ErrDuplicateRobot error = errors.New("duplicate robot")
+	ErrAborted        error = errors.New("simulation aborted")
+	ErrAlreadyRun     error = errors.New("simulation already run")
+)
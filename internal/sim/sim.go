@@ -0,0 +1,425 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/plan"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+// Durations are the number of ticks operations take, placing the robot and
+// reporting are instant
+type Durations struct {
+	// Move is the number of ticks of a single step forward or backward
+	Move int
+	// Turn is the number of ticks of a turn by 90 degrees
+	Turn int
+}
+
+// DefaultDurations are used unless `WithDurations` is given
+var DefaultDurations = Durations{Move: 2, Turn: 1}
+
+// DefaultMaxSteps is the number of commands a robot may execute unless
+// `WithMaxSteps` is given
+const DefaultMaxSteps = 1000000
+
+// Simulator runs command lists of several robots on a shared table against a
+// discrete clock. Robots act in parallel, an operation taking ticks is applied
+// once they passed and operations completing at the same tick are applied in
+// the order the robots were added. Cells taken by a robot are blocked for the
+// other ones, so a robot moving into a cell another one reached first is
+// refused like a move into a blocked cell
+type Simulator struct {
+	sizeX     uint
+	sizeY     uint
+	blocked   []point.Point
+	output    io.Writer
+	durations Durations
+	maxSteps  int
+
+	robots   []*robot
+	names    map[string]bool
+	occupied map[point.Point]*robot
+	clock    int
+	ran      bool
+}
+
+// robot is a command list run as a coroutine of the simulator, only one robot
+// runs at a time between resuming and yielding
+type robot struct {
+	name  string
+	cmds  []command.Command
+	table *table.Table
+	pos   *point.Point
+	// time is the tick the robot resumes at
+	time  int
+	stats Stats
+
+	resume chan bool
+	yield  chan struct{}
+	done   bool
+	err    error
+}
+
+// Stats describe the run of a single robot
+type Stats struct {
+	Name string
+	// Ticks is the tick the robot finished its command list at
+	Ticks int
+	// Moves is the number of steps the robot tried to make
+	Moves int
+	// Turns is the number of turns by 90 degrees the robot made
+	Turns int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%s: finished at tick %d, %d moves, %d turns", s.Name, s.Ticks, s.Moves, s.Turns)
+}
+
+// Result is the result of a simulation
+type Result struct {
+	// Ticks is the tick the last robot finished at
+	Ticks  int
+	Robots []Stats
+}
+
+// Option is an option that can be passed to `New`
+type Option func(*Simulator)
+
+// WithDurations provides an option to change the number of ticks operations take
+func WithDurations(d Durations) Option {
+	return func(s *Simulator) {
+		s.durations = d
+	}
+}
+
+// WithMaxSteps provides an option to stop the simulation once a robot executed
+// the number of commands, zero leaves robots unlimited
+func WithMaxSteps(n int) Option {
+	return func(s *Simulator) {
+		s.maxSteps = n
+	}
+}
+
+// WithBlocked provides an option to block cells of the shared table
+func WithBlocked(cells ...point.Point) Option {
+	return func(s *Simulator) {
+		s.blocked = append(s.blocked, cells...)
+	}
+}
+
+// WithReportOutput provides an option to specify custom output for reports,
+// every report is prefixed by the tick and the name of the robot
+func WithReportOutput(out io.Writer) Option {
+	return func(s *Simulator) {
+		s.output = out
+	}
+}
+
+func New(sizeX, sizeY uint, opts ...Option) *Simulator {
+	s := &Simulator{
+		sizeX:     sizeX,
+		sizeY:     sizeY,
+		output:    os.Stdout,
+		durations: DefaultDurations,
+		maxSteps:  DefaultMaxSteps,
+		names:     map[string]bool{},
+		occupied:  map[point.Point]*robot{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add adds an unplaced robot running the commands
+func (s *Simulator) Add(name string, cmds []command.Command) error {
+	if s.names[name] {
+		return fmt.Errorf("%w: '%s'", ErrDuplicateRobot, name)
+	}
+
+	r := &robot{
+		name:   name,
+		cmds:   cmds,
+		stats:  Stats{Name: name},
+		resume: make(chan bool),
+		yield:  make(chan struct{}),
+	}
+	r.table = table.New(s.sizeX, s.sizeY,
+		table.WithBlocked(s.blocked...),
+		table.WithBlockedFunc(func(pos point.Point) bool {
+			other, ok := s.occupied[pos]
+			return ok && other != r
+		}),
+		table.WithEventHook(func(e table.Event) {
+			if e.Kind == table.EventPlaced || e.Kind == table.EventMoved {
+				s.occupy(r, e.Robot.Pos)
+			}
+		}),
+		table.WithReportOutput(&reportWriter{sim: s, name: name}),
+	)
+	s.robots = append(s.robots, r)
+	s.names[name] = true
+	return nil
+}
+
+// occupy moves the robot to the cell
+func (s *Simulator) occupy(r *robot, pos point.Point) {
+	if r.pos != nil {
+		delete(s.occupied, *r.pos)
+	}
+	s.occupied[pos] = r
+	r.pos = &pos
+}
+
+// Run runs the robots until every one of them finished its command list. The
+// first failure of a command other than a refusal stops every robot and is
+// returned, a simulator can only be run once and ErrAlreadyRun is returned by
+// later runs
+func (s *Simulator) Run() (Result, error) {
+	return s.RunContext(context.Background())
+}
+
+// RunContext is `Run` that is stopped once the context is done, robots check
+// the context before every command. A robot stopped by the context or by the
+// step budget stops every robot and its `command.PartialError` is returned
+func (s *Simulator) RunContext(ctx context.Context) (Result, error) {
+	if s.ran {
+		return Result{}, ErrAlreadyRun
+	}
+	s.ran = true
+
+	for _, r := range s.robots {
+		go s.start(ctx, r)
+	}
+
+	var first error
+	for {
+		next := s.next()
+		if next == nil {
+			break
+		}
+		s.clock = next.time
+		next.resume <- true
+		<-next.yield
+
+		if next.done && next.err != nil {
+			first = next.err
+			s.abort()
+			break
+		}
+	}
+	if first != nil {
+		return Result{}, first
+	}
+
+	res := Result{}
+	for _, r := range s.robots {
+		if r.stats.Ticks > res.Ticks {
+			res.Ticks = r.stats.Ticks
+		}
+		res.Robots = append(res.Robots, r.stats)
+	}
+	return res, nil
+}
+
+// next returns the robot to resume, the earliest one and the first added one
+// among robots resuming at the same tick
+func (s *Simulator) next() *robot {
+	var next *robot
+	for _, r := range s.robots {
+		if !r.done && (next == nil || r.time < next.time) {
+			next = r
+		}
+	}
+	return next
+}
+
+// abort stops robots that did not finish yet
+func (s *Simulator) abort() {
+	for _, r := range s.robots {
+		if !r.done {
+			r.resume <- false
+			<-r.yield
+		}
+	}
+}
+
+// start runs the command list of the robot once it is resumed the first time
+func (s *Simulator) start(ctx context.Context, r *robot) {
+	defer func() {
+		r.done = true
+		r.stats.Ticks = r.time
+		r.yield <- struct{}{}
+	}()
+
+	if !<-r.resume {
+		r.err = ErrAborted
+		return
+	}
+	r.err = command.RunContext(ctx, &clockTable{Table: r.table, sim: s, robot: r}, r.cmds, command.WithMaxSteps(s.maxSteps))
+}
+
+// clockTable makes operations of the robot take ticks
+type clockTable struct {
+	*table.Table
+	sim   *Simulator
+	robot *robot
+}
+
+// wait yields until the ticks passed
+func (c *clockTable) wait(ticks int) error {
+	if ticks <= 0 {
+		return nil
+	}
+	c.robot.time += ticks
+	c.robot.yield <- struct{}{}
+	if !<-c.robot.resume {
+		return ErrAborted
+	}
+	return nil
+}
+
+// placed reports whether the robot is on the table, operations of unplaced
+// robots are refused without taking any ticks
+func (c *clockTable) placed() bool {
+	_, _, err := c.Table.Robot()
+	return err == nil
+}
+
+func (c *clockTable) MoveRobot() (*point.Point, error) {
+	if c.placed() {
+		c.robot.stats.Moves++
+		if err := c.wait(c.sim.durations.Move); err != nil {
+			return nil, err
+		}
+	}
+	return c.Table.MoveRobot()
+}
+
+// MoveRobotBy advances the robot by a cell once the ticks of every step
+// passed, so that robots crossing each other during a move meet. Unless
+// partial is set the whole move is checked before the first step and a move
+// that can not be made is refused at once, taking the ticks of its steps like
+// a refused single step does. A partial move takes the steps that can be made
+// and is only refused when there are none. A robot entering a cell ahead
+// while the move is under way stops it at the cell reached so far as the
+// ticks of the steps already taken can not be undone
+func (c *clockTable) MoveRobotBy(steps int, partial bool) (*point.Point, error) {
+	if !c.placed() || steps == 0 {
+		return c.Table.MoveRobotBy(steps, partial)
+	}
+
+	// the distance is unsigned so that negating the smallest int can not
+	// overflow
+	pos, facing, _ := c.Table.Robot()
+	step, n := 1, uint(steps)
+	if steps < 0 {
+		step, n, facing = -1, -n, facing.Opposite()
+	}
+
+	reach, err := c.reach(pos, facing, n)
+	if reach == 0 || (err != nil && !partial) {
+		return c.refuse(steps, partial, n)
+	}
+
+	var moved *point.Point
+	for i := uint(0); i < reach; i++ {
+		c.robot.stats.Moves++
+		if err := c.wait(c.sim.durations.Move); err != nil {
+			return nil, err
+		}
+		next, err := c.Table.MoveRobotBy(step, false)
+		if err != nil {
+			if partial && i > 0 {
+				return moved, nil
+			}
+			return next, err
+		}
+		moved = next
+	}
+	return moved, nil
+}
+
+// reach returns the number of cells up to n the robot can move through in the
+// direction alongside the error refusing the cell following them
+func (c *clockTable) reach(pos point.Point, facing direction.Direction, n uint) (uint, error) {
+	var reach uint
+	for reach < n {
+		pos = facing.Step(pos)
+		if err := plan.Validate(c.Table, pos); err != nil {
+			return reach, err
+		}
+		reach++
+	}
+	return reach, nil
+}
+
+// refuse refuses the move and takes the ticks of its steps, at most of as many
+// steps as the longest side of the table has cells so that huge moves can not
+// overflow the clock
+func (c *clockTable) refuse(steps int, partial bool, n uint) (*point.Point, error) {
+	pos, err := c.Table.MoveRobotBy(steps, partial)
+	longest, sizeY := c.Table.Size()
+	if sizeY > longest {
+		longest = sizeY
+	}
+	if n > longest {
+		n = longest
+	}
+	c.robot.stats.Moves += int(n)
+	if err := c.wait(int(n) * c.sim.durations.Move); err != nil {
+		return nil, err
+	}
+	return pos, err
+}
+
+func (c *clockTable) RotateRobot(left bool) (*direction.Direction, error) {
+	if c.placed() {
+		c.robot.stats.Turns++
+		if err := c.wait(c.sim.durations.Turn); err != nil {
+			return nil, err
+		}
+	}
+	return c.Table.RotateRobot(left)
+}
+
+// TurnRobot takes the ticks of every turn by 90 degrees, turns that are not
+// a multiple of 90 degrees are refused without taking any ticks
+func (c *clockTable) TurnRobot(degrees int) (*direction.Direction, error) {
+	if c.placed() && degrees%90 == 0 {
+		turns := degrees / 90 % 4
+		if turns < 0 {
+			turns = -turns
+		}
+		if turns == 3 {
+			turns = 1
+		}
+		c.robot.stats.Turns += turns
+		if err := c.wait(turns * c.sim.durations.Turn); err != nil {
+			return nil, err
+		}
+	}
+	return c.Table.TurnRobot(degrees)
+}
+
+// reportWriter prefixes reports by the tick and the name of the robot
+type reportWriter struct {
+	sim  *Simulator
+	name string
+}
+
+func (w *reportWriter) Write(b []byte) (int, error) {
+	if _, err := fmt.Fprintf(w.sim.output, "[%d] %s: %s", w.sim.clock, w.name, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,266 @@
+package sim_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/sim"
+)
+
+func parse(t *testing.T, srcs ...string) []command.Command {
+	t.Helper()
+
+	cmds := make([]command.Command, 0, len(srcs))
+	for _, src := range srcs {
+		cmd, err := command.DefaultRegistry.Parse(src)
+		require.NoError(t, err)
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	type robot struct {
+		name string
+		cmds []string
+	}
+
+	tests := [...]struct {
+		name      string
+		sizeX     uint
+		sizeY     uint
+		durations sim.Durations
+		robots    []robot
+		output    string
+		expected  sim.Result
+	}{
+		{
+			name:      "should report the tick of the report",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.DefaultDurations,
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,NORTH", "MOVE", "LEFT", "REPORT", "MOVE 2"}},
+			},
+			output: "[3] a: Robot position: (0, 1) facing: WEST\n",
+			expected: sim.Result{Ticks: 7, Robots: []sim.Stats{
+				{Name: "a", Ticks: 7, Moves: 3, Turns: 1},
+			}},
+		},
+		{
+			name:      "should not spend ticks on refusals of unplaced robot",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.Durations{Move: 5, Turn: 3},
+			robots: []robot{
+				{name: "a", cmds: []string{"MOVE", "LEFT", "PLACE 1,1,EAST", "TURN 180", "REPORT"}},
+			},
+			output: "[6] a: Robot position: (1, 1) facing: WEST\n",
+			expected: sim.Result{Ticks: 6, Robots: []sim.Stats{
+				{Name: "a", Ticks: 6, Turns: 2},
+			}},
+		},
+		{
+			name:      "should run robots in parallel",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.Durations{Move: 1, Turn: 1},
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,NORTH", "MOVE 3", "REPORT"}},
+				{name: "b", cmds: []string{"PLACE 1,0,NORTH", "MOVE", "REPORT", "RIGHT", "MOVE 3", "REPORT"}},
+			},
+			output: "[1] b: Robot position: (1, 1) facing: NORTH\n" +
+				"[3] a: Robot position: (0, 3) facing: NORTH\n" +
+				"[5] b: Robot position: (4, 1) facing: EAST\n",
+			expected: sim.Result{Ticks: 5, Robots: []sim.Stats{
+				{Name: "a", Ticks: 3, Moves: 3},
+				{Name: "b", Ticks: 5, Moves: 4, Turns: 1},
+			}},
+		},
+		{
+			name:      "should give the cell to the robot added first on the same tick",
+			sizeX:     3,
+			sizeY:     1,
+			durations: sim.DefaultDurations,
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,EAST", "MOVE", "REPORT"}},
+				{name: "b", cmds: []string{"PLACE 2,0,WEST", "MOVE", "REPORT"}},
+			},
+			output: "[2] a: Robot position: (1, 0) facing: EAST\n" +
+				"[2] b: Robot position: (2, 0) facing: WEST\n",
+			expected: sim.Result{Ticks: 2, Robots: []sim.Stats{
+				{Name: "a", Ticks: 2, Moves: 1},
+				{Name: "b", Ticks: 2, Moves: 1},
+			}},
+		},
+		{
+			name:      "should give the cell to the robot reaching it first",
+			sizeX:     3,
+			sizeY:     1,
+			durations: sim.DefaultDurations,
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,EAST", "LEFT", "RIGHT", "MOVE", "REPORT"}},
+				{name: "b", cmds: []string{"PLACE 2,0,WEST", "MOVE", "REPORT"}},
+			},
+			output: "[2] b: Robot position: (1, 0) facing: WEST\n" +
+				"[4] a: Robot position: (0, 0) facing: EAST\n",
+			expected: sim.Result{Ticks: 4, Robots: []sim.Stats{
+				{Name: "a", Ticks: 4, Moves: 1, Turns: 2},
+				{Name: "b", Ticks: 2, Moves: 1},
+			}},
+		},
+		{
+			name:      "should stop robots crossing each other during a move",
+			sizeX:     4,
+			sizeY:     1,
+			durations: sim.Durations{Move: 1, Turn: 1},
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,EAST", "MOVE 2", "REPORT"}},
+				{name: "b", cmds: []string{"PLACE 3,0,WEST", "MOVE 2", "REPORT"}},
+			},
+			output: "[2] a: Robot position: (1, 0) facing: EAST\n" +
+				"[2] b: Robot position: (2, 0) facing: WEST\n",
+			expected: sim.Result{Ticks: 2, Robots: []sim.Stats{
+				{Name: "a", Ticks: 2, Moves: 2},
+				{Name: "b", Ticks: 2, Moves: 2},
+			}},
+		},
+		{
+			name:      "should refuse a whole move leaving the table",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.Durations{Move: 1, Turn: 1},
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,NORTH", "MOVE 10", "REPORT"}},
+			},
+			output: "[5] a: Robot position: (0, 0) facing: NORTH\n",
+			expected: sim.Result{Ticks: 5, Robots: []sim.Stats{
+				{Name: "a", Ticks: 5, Moves: 5},
+			}},
+		},
+		{
+			name:      "should stop a partial move at the edge",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.Durations{Move: 1, Turn: 1},
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,NORTH", "MOVE 10,PARTIAL", "REPORT"}},
+			},
+			output: "[4] a: Robot position: (0, 4) facing: NORTH\n",
+			expected: sim.Result{Ticks: 4, Robots: []sim.Stats{
+				{Name: "a", Ticks: 4, Moves: 4},
+			}},
+		},
+		{
+			name:      "should stop a halted robot",
+			sizeX:     5,
+			sizeY:     5,
+			durations: sim.DefaultDurations,
+			robots: []robot{
+				{name: "a", cmds: []string{"PLACE 0,0,NORTH", "MOVE", "HALT", "MOVE"}},
+			},
+			expected: sim.Result{Ticks: 2, Robots: []sim.Stats{
+				{Name: "a", Ticks: 2, Moves: 1},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			s := sim.New(tt.sizeX, tt.sizeY, sim.WithDurations(tt.durations), sim.WithReportOutput(out))
+			for _, r := range tt.robots {
+				require.NoError(t, s.Add(r.name, parse(t, r.cmds...)))
+			}
+
+			res, err := s.Run()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res)
+			require.Equal(t, tt.output, out.String())
+		})
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	s := sim.New(5, 5, sim.WithReportOutput(&bytes.Buffer{}))
+	require.NoError(t, s.Add("a", parse(t, "PLACE 0,0,NORTH", "MOVE 4", "REPORT")))
+	require.NoError(t, s.Add("b", append(parse(t, "PLACE 1,0,NORTH", "MOVE"), func(t command.Table, env *command.Env) error {
+		return errBoom
+	})))
+	require.ErrorIs(t, s.Add("a", nil), sim.ErrDuplicateRobot)
+
+	_, err := s.Run()
+	require.ErrorIs(t, err, errBoom)
+}
+
+func TestRunOnce(t *testing.T) {
+	t.Parallel()
+
+	s := sim.New(5, 5, sim.WithReportOutput(&bytes.Buffer{}))
+	require.NoError(t, s.Add("a", parse(t, "PLACE 0,0,NORTH", "MOVE")))
+
+	_, err := s.Run()
+	require.NoError(t, err)
+	_, err = s.Run()
+	require.ErrorIs(t, err, sim.ErrAlreadyRun)
+}
+
+func TestRunContext(t *testing.T) {
+	t.Parallel()
+
+	turns := make([]string, 100)
+	for i := range turns {
+		turns[i] = "LEFT"
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		opts     []sim.Option
+		expected error
+	}{
+		{
+			name:     "should stop a robot exhausting the step budget",
+			ctx:      context.Background(),
+			opts:     []sim.Option{sim.WithMaxSteps(10)},
+			expected: command.ErrMaxSteps,
+		},
+		{
+			name:     "should stop robots once the context is done",
+			ctx:      canceled,
+			expected: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sim.New(5, 5, append(tt.opts, sim.WithReportOutput(&bytes.Buffer{}))...)
+			require.NoError(t, s.Add("a", parse(t, append([]string{"PLACE 0,0,NORTH"}, turns...)...)))
+			require.NoError(t, s.Add("b", parse(t, "PLACE 1,0,NORTH", "MOVE")))
+
+			_, err := s.RunContext(tt.ctx)
+			require.ErrorIs(t, err, tt.expected)
+			var partial *command.PartialError
+			require.ErrorAs(t, err, &partial)
+		})
+	}
+}
@@ -5,4 +5,14 @@ import "errors"
 var (
 	ErrUninitializedPlacement    error = errors.New("uninitialized placement")
 	ErrEndingPositionOutOfBounds error = errors.New("ending position out of bounds")
+	ErrPositionBlocked           error = errors.New("position blocked")
 )
+
+// IsRefusal reports whether the error was returned by the table refusing to
+// perform an operation, refusals leave the robot where it was and commands
+// running into them are ignored
+func IsRefusal(err error) bool {
+	return errors.Is(err, ErrUninitializedPlacement) ||
+		errors.Is(err, ErrEndingPositionOutOfBounds) ||
+		errors.Is(err, ErrPositionBlocked)
+}
@@ -0,0 +1,38 @@
+package table_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/direction"
+	"robot/internal/table"
+)
+
+func TestIsRefusal(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "should not treat nil as refusal", err: nil, expected: false},
+		{name: "should detect uninitialized placement", err: table.ErrUninitializedPlacement, expected: true},
+		{name: "should detect out of bounds", err: table.ErrEndingPositionOutOfBounds, expected: true},
+		{name: "should detect wrapped blocked position", err: fmt.Errorf("line 3: %w", table.ErrPositionBlocked), expected: true},
+		{name: "should not treat invalid angle as refusal", err: direction.ErrInvalidAngle, expected: false},
+		{name: "should not treat other errors as refusal", err: errors.New("boom"), expected: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, table.IsRefusal(tt.err))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package table
+
+// EventKind tells what happened to the robot
+type EventKind int
+
+const (
+	// EventPlaced is emitted after the robot was placed
+	EventPlaced EventKind = iota
+	// EventMoved is emitted after the robot moved
+	EventMoved
+	// EventRotated is emitted after the robot changed its facing
+	EventRotated
+	// EventRefused is emitted when an operation failed, the robot did not
+	// change
+	EventRefused
+	// EventReported is emitted after the robot was reported
+	EventReported
+)
+
+// String returns the lower case name of EventKind
+func (k EventKind) String() string {
+	switch k {
+	case EventPlaced:
+		return "placed"
+	case EventMoved:
+		return "moved"
+	case EventRotated:
+		return "rotated"
+	case EventRefused:
+		return "refused"
+	default:
+		return "reported"
+	}
+}
+
+// Event describes an operation performed on the table
+type Event struct {
+	Kind EventKind
+	// Op is the name of the table method, e.g. MoveRobot
+	Op string
+	// Robot is the pose after the operation, nil when the robot is not placed
+	Robot *Pose
+	// Err is the reason of the refusal
+	Err error
+}
+
+// EventHook is called synchronously after every operation on the table
+type EventHook func(e Event)
+
+// WithEventHook provides an option to observe operations on the table, hooks
+// are called in the order they were given
+func WithEventHook(hook EventHook) Option {
+	return func(t *Table) {
+		t.hooks = append(t.hooks, hook)
+	}
+}
+
+// emit calls the hooks with the event of the operation, a non nil error makes
+// it a refusal
+func (t *Table) emit(kind EventKind, op string, err error) {
+	if len(t.hooks) == 0 {
+		return
+	}
+
+	e := Event{Kind: kind, Op: op, Err: err}
+	if err != nil {
+		e.Kind = EventRefused
+	}
+	e.Robot = t.pose()
+	for _, hook := range t.hooks {
+		hook(e)
+	}
+}
@@ -0,0 +1,133 @@
+package table_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+func TestEventHook(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		ops      func(tbl *table.Table)
+		expected []string
+	}{
+		{
+			name: "should emit refusals before placement",
+			ops: func(tbl *table.Table) {
+				tbl.MoveRobot()
+				tbl.RotateRobot(true)
+				tbl.Report()
+			},
+			expected: []string{
+				"refused MoveRobot -: uninitialized placement",
+				"refused RotateRobot -: uninitialized placement",
+				"refused Report -: uninitialized placement",
+			},
+		},
+		{
+			name: "should emit events with the pose after the operation",
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				tbl.MoveRobot()
+				tbl.RotateRobot(false)
+				tbl.TurnRobot(180)
+				tbl.Report()
+			},
+			expected: []string{
+				"placed PlaceRobot 1,1,NORTH",
+				"moved MoveRobot 1,2,NORTH",
+				"rotated RotateRobot 1,2,EAST",
+				"rotated TurnRobot 1,2,WEST",
+				"reported Report 1,2,WEST",
+			},
+		},
+		{
+			name: "should emit refusals of invalid operations",
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 2, Y: 2}, direction.North)
+				tbl.PlaceRobot(point.Point{X: 0, Y: 0}, direction.North)
+				tbl.MoveRobot()
+				tbl.TurnRobot(45)
+			},
+			expected: []string{
+				"refused PlaceRobot -: position blocked",
+				"placed PlaceRobot 0,0,NORTH",
+				"moved MoveRobot 0,1,NORTH",
+				"refused TurnRobot 0,1,NORTH: angle is not a multiple of 90 degrees",
+			},
+		},
+		{
+			name: "should refuse partial move only when no step can be taken",
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 3, Y: 0}, direction.East)
+				tbl.MoveRobotBy(3, false)
+				tbl.MoveRobotBy(3, true)
+				tbl.MoveRobotBy(3, true)
+			},
+			expected: []string{
+				"placed PlaceRobot 3,0,EAST",
+				"refused MoveRobotBy 3,0,EAST: ending position out of bounds",
+				"moved MoveRobotBy 4,0,EAST",
+				"refused MoveRobotBy 4,0,EAST: ending position out of bounds",
+			},
+		},
+		{
+			name: "should emit every step of a move",
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 0, Y: 0}, direction.North)
+				tbl.MoveRobotBy(2, false)
+				tbl.MoveRobotBy(-3, true)
+			},
+			expected: []string{
+				"placed PlaceRobot 0,0,NORTH",
+				"moved MoveRobotBy 0,1,NORTH",
+				"moved MoveRobotBy 0,2,NORTH",
+				"moved MoveRobotBy 0,1,NORTH",
+				"moved MoveRobotBy 0,0,NORTH",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var actual []string
+			hook := func(e table.Event) {
+				robot := "-"
+				if e.Robot != nil {
+					robot = e.Robot.String()
+				}
+				s := fmt.Sprintf("%s %s %s", e.Kind, e.Op, robot)
+				if e.Err != nil {
+					s += ": " + e.Err.Error()
+				}
+				actual = append(actual, s)
+			}
+			var calls int
+			counter := func(e table.Event) {
+				calls++
+			}
+
+			tbl := table.New(5, 5,
+				table.WithReportOutput(bytes.NewBufferString("")),
+				table.WithBlocked(point.Point{X: 2, Y: 2}),
+				table.WithEventHook(hook),
+				table.WithEventHook(counter),
+			)
+			tt.ops(tbl)
+			require.Equal(t, tt.expected, actual)
+			require.Equal(t, len(tt.expected), calls)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package table
+
+import (
+	"fmt"
+	"strings"
+
+	"robot/internal/point"
+)
+
+const (
+	// freeCell marks a cell the robot can be placed on in a layout
+	freeCell = '.'
+	// blockedCell marks a blocked cell in a layout
+	blockedCell = '#'
+)
+
+// Layout is the size of the table and its blocked cells
+type Layout struct {
+	SizeX        uint
+	SizeY        uint
+	BlockedCells []point.Point
+}
+
+// ParseLayout parses rows of '.' free and '#' blocked cells, the first row is
+// the one furthest to the north so that the layout reads like the table seen
+// from above. Blank lines are skipped and every row must have the same width
+func ParseLayout(src []byte) (Layout, error) {
+	rows := []string{}
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) == 0 {
+		return Layout{}, fmt.Errorf("layout has no rows")
+	}
+
+	l := Layout{
+		SizeX: uint(len(rows[0])),
+		SizeY: uint(len(rows)),
+	}
+	for i, row := range rows {
+		if uint(len(row)) != l.SizeX {
+			return Layout{}, fmt.Errorf("layout row %d has %d cells, but %d were expected", i+1, len(row), l.SizeX)
+		}
+		y := len(rows) - 1 - i
+		for x, cell := range row {
+			switch cell {
+			case freeCell:
+			case blockedCell:
+				l.BlockedCells = append(l.BlockedCells, point.Point{X: x, Y: y})
+			default:
+				return Layout{}, fmt.Errorf("layout row %d has invalid cell '%c'", i+1, cell)
+			}
+		}
+	}
+	return l, nil
+}
+
+// Size returns the table dimensions alongside X and Y axis
+func (l Layout) Size() (uint, uint) {
+	return l.SizeX, l.SizeY
+}
+
+// Blocked reports whether the cell is blocked
+func (l Layout) Blocked(pos point.Point) bool {
+	for _, cell := range l.BlockedCells {
+		if cell == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the layout in the form parsed by `ParseLayout`
+func (l Layout) String() string {
+	var sb strings.Builder
+	for y := int(l.SizeY) - 1; y >= 0; y-- {
+		for x := 0; x < int(l.SizeX); x++ {
+			if l.Blocked(point.Point{X: x, Y: y}) {
+				sb.WriteRune(blockedCell)
+				continue
+			}
+			sb.WriteRune(freeCell)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
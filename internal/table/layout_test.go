@@ -0,0 +1,52 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+func TestParseLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		src       string
+		expected  table.Layout
+		expectedS string
+		shouldErr bool
+	}{
+		{
+			name: "should parse layout with the first row furthest to the north",
+			src:  "\n#..\n..#\n\n",
+			expected: table.Layout{
+				SizeX:        3,
+				SizeY:        2,
+				BlockedCells: []point.Point{{X: 0, Y: 1}, {X: 2, Y: 0}},
+			},
+			expectedS: "#..\n..#\n",
+		},
+		{name: "should fail to parse empty layout", src: "\n", shouldErr: true},
+		{name: "should fail to parse rows of different width", src: "..\n...\n", shouldErr: true},
+		{name: "should fail to parse invalid cell", src: ".x.\n", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := table.ParseLayout([]byte(tt.src))
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+			require.Equal(t, tt.expectedS, actual.String())
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package table
+
+// Phase tells whether an observation is made before or after the operation
+type Phase int
+
+const (
+	PhaseBefore Phase = iota
+	PhaseAfter
+)
+
+// String returns the lower case name of Phase
+func (p Phase) String() string {
+	if p == PhaseBefore {
+		return "before"
+	}
+	return "after"
+}
+
+// Observation describes an operation about to be performed on the table or
+// one that was just performed
+type Observation struct {
+	Phase Phase
+	// Op is the name of the table method, e.g. MoveRobot
+	Op string
+	// Robot is the pose at the time of the observation, nil when the robot is
+	// not placed
+	Robot *Pose
+	// Target is the pose the operation aims for both before and after it, it
+	// may be off the table. It is nil for reports and for operations the robot
+	// can not perform
+	Target *Pose
+	// Err is the error the operation returned, it is only set after it
+	Err error
+}
+
+// Observer is called synchronously before and after every operation on the
+// table. An error returned before the operation vetoes it, the operation is
+// not performed and returns the error. Errors returned after the operation
+// are ignored
+type Observer func(o Observation) error
+
+// WithObserver provides an option to observe and veto operations on the
+// table, observers are called in the order they were given. Once an observer
+// vetoes an operation the following ones are not asked, but every observer is
+// called after the operation, vetoed or not
+func WithObserver(observer Observer) Option {
+	return func(t *Table) {
+		t.observers = append(t.observers, observer)
+	}
+}
+
+// observe performs the operation between the observations, a vetoed
+// operation is emitted as a refusal. The target is only computed when there
+// are observers, it may be nil for operations without one
+func (t *Table) observe(op string, target func() *Pose, fn func() error) error {
+	if len(t.observers) == 0 {
+		return fn()
+	}
+
+	o := Observation{Phase: PhaseBefore, Op: op, Robot: t.pose()}
+	if target != nil {
+		o.Target = target()
+	}
+	var err error
+	for _, observer := range t.observers {
+		if err = observer(o); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		t.emit(EventRefused, op, err)
+	} else {
+		err = fn()
+	}
+
+	o.Phase, o.Robot, o.Err = PhaseAfter, t.pose(), err
+	for _, observer := range t.observers {
+		observer(o)
+	}
+	return err
+}
+
+// pose returns the current pose of the robot, nil when it is not placed
+func (t *Table) pose() *Pose {
+	if t.robotPosition == nil {
+		return nil
+	}
+	return &Pose{Pos: *t.robotPosition, Facing: *t.robotFacing}
+}
+
+// ahead returns the target of moving by the number of steps
+func (t *Table) ahead(steps int) func() *Pose {
+	return func() *Pose {
+		p := t.pose()
+		if p == nil {
+			return nil
+		}
+		p.Pos.X += p.Facing.DX() * steps
+		p.Pos.Y += p.Facing.DY() * steps
+		return p
+	}
+}
+
+// turned returns the target of turning by the number of degrees
+func (t *Table) turned(degrees int) func() *Pose {
+	return func() *Pose {
+		p := t.pose()
+		if p == nil || p.Facing.Turn(degrees) != nil {
+			return nil
+		}
+		return p
+	}
+}
@@ -0,0 +1,164 @@
+package table_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+var errNoEast = errors.New("facing east is forbidden")
+
+func describe(name string, o table.Observation) string {
+	robot, target := "-", "-"
+	if o.Robot != nil {
+		robot = o.Robot.String()
+	}
+	if o.Target != nil {
+		target = o.Target.String()
+	}
+	s := fmt.Sprintf("%s %s %s %s %s", name, o.Phase, o.Op, robot, target)
+	if o.Err != nil {
+		s += ": " + o.Err.Error()
+	}
+	return s
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		observers []string
+		ops       func(tbl *table.Table)
+		expected  []string
+	}{
+		{
+			name:      "should observe operations before and after",
+			observers: []string{"log"},
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				tbl.MoveRobotBy(2, false)
+				tbl.TurnRobot(180)
+				tbl.Report()
+			},
+			expected: []string{
+				"log before PlaceRobot - 1,1,NORTH",
+				"log after PlaceRobot 1,1,NORTH 1,1,NORTH",
+				"log before MoveRobotBy 1,1,NORTH 1,3,NORTH",
+				"log after MoveRobotBy 1,3,NORTH 1,3,NORTH",
+				"log before TurnRobot 1,3,NORTH 1,3,SOUTH",
+				"log after TurnRobot 1,3,SOUTH 1,3,SOUTH",
+				"log before Report 1,3,SOUTH -",
+				"log after Report 1,3,SOUTH -",
+			},
+		},
+		{
+			name:      "should observe refusals after the operation",
+			observers: []string{"log"},
+			ops: func(tbl *table.Table) {
+				tbl.MoveRobot()
+				tbl.PlaceRobot(point.Point{X: 0, Y: 4}, direction.North)
+				tbl.MoveRobot()
+			},
+			expected: []string{
+				"log before MoveRobot - -",
+				"log after MoveRobot - -: uninitialized placement",
+				"log before PlaceRobot - 0,4,NORTH",
+				"log after PlaceRobot 0,4,NORTH 0,4,NORTH",
+				"log before MoveRobot 0,4,NORTH 0,5,NORTH",
+				"log after MoveRobot 0,4,NORTH 0,5,NORTH: ending position out of bounds",
+			},
+		},
+		{
+			name:      "should call observers in order and skip the ones following a veto",
+			observers: []string{"log", "rule", "audit"},
+			ops: func(tbl *table.Table) {
+				tbl.PlaceRobot(point.Point{X: 2, Y: 2}, direction.North)
+				tbl.RotateRobot(false)
+			},
+			expected: []string{
+				"log before PlaceRobot - 2,2,NORTH",
+				"rule before PlaceRobot - 2,2,NORTH",
+				"audit before PlaceRobot - 2,2,NORTH",
+				"log after PlaceRobot 2,2,NORTH 2,2,NORTH",
+				"rule after PlaceRobot 2,2,NORTH 2,2,NORTH",
+				"audit after PlaceRobot 2,2,NORTH 2,2,NORTH",
+				"log before RotateRobot 2,2,NORTH 2,2,EAST",
+				"rule before RotateRobot 2,2,NORTH 2,2,EAST",
+				"log after RotateRobot 2,2,NORTH 2,2,EAST: facing east is forbidden",
+				"rule after RotateRobot 2,2,NORTH 2,2,EAST: facing east is forbidden",
+				"audit after RotateRobot 2,2,NORTH 2,2,EAST: facing east is forbidden",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := []string{}
+			opts := []table.Option{table.WithReportOutput(&bytes.Buffer{})}
+			for _, name := range tt.observers {
+				name := name
+				opts = append(opts, table.WithObserver(func(o table.Observation) error {
+					actual = append(actual, describe(name, o))
+					if name == "rule" {
+						return noEast(o)
+					}
+					return nil
+				}))
+			}
+
+			tt.ops(table.New(5, 5, opts...))
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestObserverVeto(t *testing.T) {
+	t.Parallel()
+
+	events := []string{}
+	tbl := table.New(5, 5,
+		table.WithObserver(noEast),
+		table.WithEventHook(func(e table.Event) {
+			events = append(events, fmt.Sprintf("%s %s", e.Kind, e.Op))
+		}),
+	)
+
+	require.ErrorIs(t, tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.East), errNoEast)
+	_, _, err := tbl.Robot()
+	require.ErrorIs(t, err, table.ErrUninitializedPlacement)
+
+	require.NoError(t, tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.South))
+	facing, err := tbl.TurnRobot(90)
+	require.ErrorIs(t, err, errNoEast)
+	require.Equal(t, direction.South, *facing)
+
+	pos, err := tbl.MoveRobot()
+	require.NoError(t, err)
+	require.Equal(t, point.Point{X: 1, Y: 0}, *pos)
+
+	require.Equal(t, []string{
+		"refused PlaceRobot",
+		"placed PlaceRobot",
+		"refused TurnRobot",
+		"moved MoveRobot",
+	}, events)
+}
+
+// noEast vetoes operations that would make the robot face east
+func noEast(o table.Observation) error {
+	if o.Phase == table.PhaseBefore && o.Target != nil && o.Target.Facing == direction.East {
+		return errNoEast
+	}
+	return nil
+}
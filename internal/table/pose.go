@@ -0,0 +1,57 @@
+package table
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+)
+
+// Pose is a position and facing of the robot
+type Pose struct {
+	Pos    point.Point
+	Facing direction.Direction
+}
+
+// String returns a string representation of Pose in the PLACE parameters form
+func (p Pose) String() string {
+	return fmt.Sprintf("%d,%d,%s", p.Pos.X, p.Pos.Y, p.Facing)
+}
+
+// ParsePose parses pose given in the X,Y,F form
+func ParsePose(s string) (Pose, error) {
+	params := strings.Split(s, ",")
+	if len(params) != 3 {
+		return Pose{}, fmt.Errorf("pose requires X,Y,F form: '%s'", s)
+	}
+
+	x, err := strconv.Atoi(strings.TrimSpace(params[0]))
+	if err != nil {
+		return Pose{}, fmt.Errorf("pose x is not a number(%s)", strings.TrimSpace(params[0]))
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(params[1]))
+	if err != nil {
+		return Pose{}, fmt.Errorf("pose y is not a number(%s)", strings.TrimSpace(params[1]))
+	}
+	facing, err := direction.Parse(strings.ToUpper(strings.TrimSpace(params[2])))
+	if err != nil {
+		return Pose{}, err
+	}
+	return Pose{Pos: point.Point{X: x, Y: y}, Facing: facing}, nil
+}
+
+// Poses returns every pose on the table of the given size ordered by
+// position and facing
+func Poses(sizeX, sizeY uint) []Pose {
+	poses := make([]Pose, 0, sizeX*sizeY*4)
+	for y := 0; y < int(sizeY); y++ {
+		for x := 0; x < int(sizeX); x++ {
+			for _, facing := range direction.All() {
+				poses = append(poses, Pose{Pos: point.Point{X: x, Y: y}, Facing: facing})
+			}
+		}
+	}
+	return poses
+}
@@ -0,0 +1,57 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+func TestParsePose(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		src       string
+		expected  table.Pose
+		shouldErr bool
+	}{
+		{name: "should parse pose", src: "1,2,NORTH", expected: table.Pose{Pos: point.Point{X: 1, Y: 2}, Facing: direction.North}},
+		{name: "should parse pose with spaces and lower case facing", src: "0, 4, south", expected: table.Pose{Pos: point.Point{X: 0, Y: 4}, Facing: direction.South}},
+		{name: "should fail to parse pose without facing", src: "1,2", shouldErr: true},
+		{name: "should fail to parse pose with invalid x", src: "a,2,EAST", shouldErr: true},
+		{name: "should fail to parse pose with invalid facing", src: "1,2,UP", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := table.ParsePose(tt.src)
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestPoses(t *testing.T) {
+	t.Parallel()
+
+	poses := table.Poses(2, 1)
+	actual := []string{}
+	for _, pose := range poses {
+		actual = append(actual, pose.String())
+	}
+	require.Equal(t, []string{
+		"0,0,EAST", "0,0,NORTH", "0,0,WEST", "0,0,SOUTH",
+		"1,0,EAST", "1,0,NORTH", "1,0,WEST", "1,0,SOUTH",
+	}, actual)
+}
@@ -0,0 +1,107 @@
+package table
+
+import (
+	"sync"
+
+	"robot/internal/direction"
+	"robot/internal/point"
+)
+
+// Synced is a table safe for use by several goroutines, every operation holds
+// the lock of the table and returned positions and facings are copies that are
+// not changed by later operations
+type Synced struct {
+	mu    sync.Mutex
+	table *Table
+}
+
+// NewSynced wraps the table, it must not be used directly afterwards
+func NewSynced(t *Table) *Synced {
+	return &Synced{table: t}
+}
+
+// Atomic runs the operations of fn while no other goroutine can use the table.
+// When fn returns an error the robot is put back to the pose it had before, so
+// multi-step operations either apply as a whole or not at all. Events emitted
+// and reports written by fn are not undone
+func (s *Synced) Atomic(fn func(t *Table) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos, facing := s.table.robotPosition, s.table.robotFacing
+	if facing != nil {
+		f := *facing
+		facing = &f
+	}
+	if err := fn(s.table); err != nil {
+		s.table.robotPosition, s.table.robotFacing = pos, facing
+		return err
+	}
+	return nil
+}
+
+func (s *Synced) Size() (uint, uint) {
+	return s.table.Size()
+}
+
+func (s *Synced) Robot() (point.Point, direction.Direction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table.Robot()
+}
+
+func (s *Synced) Blocked(pos point.Point) bool {
+	return s.table.Blocked(pos)
+}
+
+func (s *Synced) PlaceRobot(pos point.Point, facing direction.Direction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table.PlaceRobot(pos, facing)
+}
+
+func (s *Synced) MoveRobot() (*point.Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyPoint(s.table.MoveRobot())
+}
+
+func (s *Synced) MoveRobotBy(steps int, partial bool) (*point.Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyPoint(s.table.MoveRobotBy(steps, partial))
+}
+
+func (s *Synced) RotateRobot(left bool) (*direction.Direction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyDirection(s.table.RotateRobot(left))
+}
+
+func (s *Synced) TurnRobot(degrees int) (*direction.Direction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyDirection(s.table.TurnRobot(degrees))
+}
+
+func (s *Synced) Report() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table.Report()
+}
+
+func copyPoint(pos *point.Point, err error) (*point.Point, error) {
+	if pos == nil {
+		return nil, err
+	}
+	p := *pos
+	return &p, err
+}
+
+func copyDirection(facing *direction.Direction, err error) (*direction.Direction, error) {
+	if facing == nil {
+		return nil, err
+	}
+	f := *facing
+	return &f, err
+}
@@ -0,0 +1,170 @@
+package table_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/command"
+	"robot/internal/direction"
+	"robot/internal/point"
+	"robot/internal/table"
+)
+
+var _ command.Table = (*table.Synced)(nil)
+
+func TestSyncedAtomic(t *testing.T) {
+	t.Parallel()
+
+	errAbort := errors.New("abort")
+
+	tests := [...]struct {
+		name     string
+		place    bool
+		fn       func(tbl *table.Table) error
+		expected string
+		err      error
+	}{
+		{
+			name:  "should apply every operation when all succeed",
+			place: true,
+			fn: func(tbl *table.Table) error {
+				if _, err := tbl.RotateRobot(false); err != nil {
+					return err
+				}
+				_, err := tbl.MoveRobot()
+				return err
+			},
+			expected: "1,0,EAST",
+		},
+		{
+			name:  "should not rotate when the move is refused",
+			place: true,
+			fn: func(tbl *table.Table) error {
+				if _, err := tbl.RotateRobot(true); err != nil {
+					return err
+				}
+				_, err := tbl.MoveRobot()
+				return err
+			},
+			expected: "0,0,NORTH",
+			err:      table.ErrEndingPositionOutOfBounds,
+		},
+		{
+			name:  "should undo moves when the transaction is aborted",
+			place: true,
+			fn: func(tbl *table.Table) error {
+				tbl.MoveRobotBy(2, false)
+				tbl.TurnRobot(-90)
+				return errAbort
+			},
+			expected: "0,0,NORTH",
+			err:      errAbort,
+		},
+		{
+			name: "should undo the placement when the transaction is aborted",
+			fn: func(tbl *table.Table) error {
+				if err := tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.South); err != nil {
+					return err
+				}
+				_, err := tbl.MoveRobotBy(2, false)
+				return err
+			},
+			expected: "-",
+			err:      table.ErrEndingPositionOutOfBounds,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tbl := table.NewSynced(table.New(3, 3))
+			if tt.place {
+				require.NoError(t, tbl.PlaceRobot(point.Point{X: 0, Y: 0}, direction.North))
+			}
+
+			err := tbl.Atomic(tt.fn)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.expected, pose(tbl))
+		})
+	}
+}
+
+func TestSyncedConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		workers    = 16
+		iterations = 200
+	)
+
+	out := &bytes.Buffer{}
+	tbl := table.NewSynced(table.New(5, 5, table.WithReportOutput(out)))
+	require.NoError(t, tbl.PlaceRobot(point.Point{X: 2, Y: 2}, direction.North))
+
+	var wg sync.WaitGroup
+	failures := make(chan string, workers*iterations)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				switch (w + i) % 4 {
+				case 0:
+					// moves forth and back so the position never changes
+					// between transactions
+					tbl.Atomic(func(t *table.Table) error {
+						if _, err := t.MoveRobot(); err != nil {
+							return err
+						}
+						_, err := t.MoveRobotBy(-1, false)
+						return err
+					})
+				case 1:
+					// the second move always fails from the center of the
+					// table and has to undo the first one
+					tbl.Atomic(func(t *table.Table) error {
+						if _, err := t.MoveRobotBy(2, false); err != nil {
+							return err
+						}
+						_, err := t.MoveRobot()
+						return err
+					})
+				case 2:
+					tbl.RotateRobot(w%2 == 0)
+					tbl.TurnRobot(180)
+				case 3:
+					tbl.Report()
+				}
+
+				if pos, _, err := tbl.Robot(); err != nil || pos != (point.Point{X: 2, Y: 2}) {
+					failures <- pose(tbl)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(failures)
+
+	for f := range failures {
+		require.Fail(t, "robot left the center of the table", f)
+	}
+	require.Equal(t, workers*iterations/4, strings.Count(out.String(), "\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		require.True(t, strings.HasPrefix(line, "Robot position: (2, 2) facing: "), line)
+	}
+}
+
+func pose(tbl *table.Synced) string {
+	pos, facing, err := tbl.Robot()
+	if err != nil {
+		return "-"
+	}
+	return table.Pose{Pos: pos, Facing: facing}.String()
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"robot/internal/direction"
 	"robot/internal/point"
@@ -15,6 +17,10 @@ type Table struct {
 	robotPosition *point.Point
 	robotFacing   *direction.Direction
 	reportOutput  io.Writer
+	blocked       map[point.Point]bool
+	blockedFunc   func(pos point.Point) bool
+	hooks         []EventHook
+	observers     []Observer
 }
 
 // Option is an option that can be passed to `New`
@@ -27,11 +33,32 @@ func WithReportOutput(out io.Writer) Option {
 	}
 }
 
+// WithBlocked provides an option to block cells of the table, the robot can
+// neither be placed on nor move through them
+func WithBlocked(cells ...point.Point) Option {
+	return func(t *Table) {
+		for _, cell := range cells {
+			t.blocked[cell] = true
+		}
+	}
+}
+
+// WithBlockedFunc provides an option to block cells for which fn returns true
+// in addition to the ones given to `WithBlocked`. It is called on every check
+// so the cells it blocks may change between operations, e.g. when they are
+// taken by other robots
+func WithBlockedFunc(fn func(pos point.Point) bool) Option {
+	return func(t *Table) {
+		t.blockedFunc = fn
+	}
+}
+
 func New(sizeX, sizeY uint, opts ...Option) *Table {
 	tbl := &Table{
 		sizeX:        sizeX,
 		sizeY:        sizeY,
 		reportOutput: os.Stdout,
+		blocked:      map[point.Point]bool{},
 	}
 
 	for _, opt := range opts {
@@ -41,6 +68,43 @@ func New(sizeX, sizeY uint, opts ...Option) *Table {
 	return tbl
 }
 
+// ParseSize parses table dimensions given in the WIDTHxHEIGHT form
+func ParseSize(s string) (uint, uint, error) {
+	dims := strings.Split(strings.ToLower(strings.TrimSpace(s)), "x")
+	if len(dims) != 2 {
+		return 0, 0, fmt.Errorf("table size requires WIDTHxHEIGHT form: '%s'", s)
+	}
+
+	sizeX, err := strconv.ParseUint(dims[0], 10, 0)
+	if err != nil || sizeX == 0 {
+		return 0, 0, fmt.Errorf("table width is not a positive number(%s)", dims[0])
+	}
+	sizeY, err := strconv.ParseUint(dims[1], 10, 0)
+	if err != nil || sizeY == 0 {
+		return 0, 0, fmt.Errorf("table height is not a positive number(%s)", dims[1])
+	}
+	return uint(sizeX), uint(sizeY), nil
+}
+
+// Size returns the table dimensions alongside X and Y axis
+func (t *Table) Size() (uint, uint) {
+	return t.sizeX, t.sizeY
+}
+
+// Robot returns the robot position and facing
+func (t *Table) Robot() (point.Point, direction.Direction, error) {
+	if t.robotPosition == nil {
+		return point.Point{}, direction.Direction{}, ErrUninitializedPlacement
+	}
+
+	return *t.robotPosition, *t.robotFacing, nil
+}
+
+// Blocked reports whether the cell is blocked
+func (t *Table) Blocked(pos point.Point) bool {
+	return t.blocked[pos] || (t.blockedFunc != nil && t.blockedFunc(pos))
+}
+
 func (t *Table) validatePosition(pos point.Point) error {
 	if pos.X < 0 || uint(pos.X) >= t.sizeX {
 		return ErrEndingPositionOutOfBounds
@@ -50,22 +114,47 @@ func (t *Table) validatePosition(pos point.Point) error {
 		return ErrEndingPositionOutOfBounds
 	}
 
+	if t.Blocked(pos) {
+		return ErrPositionBlocked
+	}
+
 	return nil
 }
 
 func (t *Table) PlaceRobot(pos point.Point, facing direction.Direction) error {
+	target := func() *Pose {
+		return &Pose{Pos: pos, Facing: facing}
+	}
+	return t.observe("PlaceRobot", target, func() error {
+		return t.placeRobot(pos, facing)
+	})
+}
+
+func (t *Table) placeRobot(pos point.Point, facing direction.Direction) error {
 	err := t.validatePosition(pos)
 	if err != nil {
+		t.emit(EventRefused, "PlaceRobot", err)
 		return err
 	}
 
 	t.robotPosition = &pos
 	t.robotFacing = &facing
+	t.emit(EventPlaced, "PlaceRobot", nil)
 	return nil
 }
 
 func (t *Table) MoveRobot() (*point.Point, error) {
+	pos := t.robotPosition
+	err := t.observe("MoveRobot", t.ahead(1), func() (err error) {
+		pos, err = t.moveRobot()
+		return err
+	})
+	return pos, err
+}
+
+func (t *Table) moveRobot() (*point.Point, error) {
 	if t.robotPosition == nil {
+		t.emit(EventRefused, "MoveRobot", ErrUninitializedPlacement)
 		return nil, ErrUninitializedPlacement
 	}
 
@@ -75,32 +164,140 @@ func (t *Table) MoveRobot() (*point.Point, error) {
 
 	err := t.validatePosition(pos)
 	if err != nil {
+		t.emit(EventRefused, "MoveRobot", err)
 		return t.robotPosition, err
 	}
 
 	t.robotPosition = &pos
+	t.emit(EventMoved, "MoveRobot", nil)
 	return t.robotPosition, nil
 }
 
+// MoveRobotBy moves the robot by the number of steps, negative steps move it
+// backwards without changing its facing. Unless partial is set the robot moves
+// only when every step stays on the table, otherwise it advances step by step
+// and stops at the edge returning the position it reached. A partial move is
+// only refused when the robot can not take a single step. Every step taken is
+// emitted as a move
+func (t *Table) MoveRobotBy(steps int, partial bool) (*point.Point, error) {
+	pos := t.robotPosition
+	err := t.observe("MoveRobotBy", t.ahead(steps), func() (err error) {
+		pos, err = t.moveRobotBy(steps, partial)
+		return err
+	})
+	return pos, err
+}
+
+func (t *Table) moveRobotBy(steps int, partial bool) (*point.Point, error) {
+	if t.robotPosition == nil {
+		t.emit(EventRefused, "MoveRobotBy", ErrUninitializedPlacement)
+		return nil, ErrUninitializedPlacement
+	}
+
+	// the distance is unsigned so that negating the smallest int can not
+	// overflow
+	facing, n := *t.robotFacing, uint(steps)
+	if steps < 0 {
+		facing, n = facing.Opposite(), -n
+	}
+
+	// count the cells the robot can reach one at a time, a straight walk
+	// leaves the table after at most its longest side so the loop is bounded
+	// by the size of the table and not by the number of steps
+	var reach uint
+	var err error
+	pos := *t.robotPosition
+	for reach < n {
+		pos.X += facing.DX()
+		pos.Y += facing.DY()
+		if err = t.validatePosition(pos); err != nil {
+			break
+		}
+		reach++
+	}
+
+	if partial && reach > 0 {
+		err = nil
+	}
+	if err == nil {
+		for i := uint(0); i < reach; i++ {
+			next := facing.Step(*t.robotPosition)
+			t.robotPosition = &next
+			t.emit(EventMoved, "MoveRobotBy", nil)
+		}
+	}
+	if err != nil {
+		t.emit(EventRefused, "MoveRobotBy", err)
+	}
+	return t.robotPosition, err
+}
+
 func (t *Table) RotateRobot(left bool) (*direction.Direction, error) {
+	degrees := -90
+	if left {
+		degrees = 90
+	}
+	facing := t.robotFacing
+	err := t.observe("RotateRobot", t.turned(degrees), func() (err error) {
+		facing, err = t.rotateRobot(left)
+		return err
+	})
+	return facing, err
+}
+
+func (t *Table) rotateRobot(left bool) (*direction.Direction, error) {
 	if t.robotPosition == nil {
+		t.emit(EventRefused, "RotateRobot", ErrUninitializedPlacement)
 		return nil, ErrUninitializedPlacement
 	}
 
 	if left {
 		t.robotFacing.RotateLeft()
+		t.emit(EventRotated, "RotateRobot", nil)
 		return t.robotFacing, nil
 	}
 
 	t.robotFacing.RotateRight()
+	t.emit(EventRotated, "RotateRobot", nil)
+	return t.robotFacing, nil
+}
+
+// TurnRobot rotates the robot by the number of degrees, positive angles turn
+// it counterclockwise and negative ones clockwise
+func (t *Table) TurnRobot(degrees int) (*direction.Direction, error) {
+	facing := t.robotFacing
+	err := t.observe("TurnRobot", t.turned(degrees), func() (err error) {
+		facing, err = t.turnRobot(degrees)
+		return err
+	})
+	return facing, err
+}
+
+func (t *Table) turnRobot(degrees int) (*direction.Direction, error) {
+	if t.robotPosition == nil {
+		t.emit(EventRefused, "TurnRobot", ErrUninitializedPlacement)
+		return nil, ErrUninitializedPlacement
+	}
+
+	if err := t.robotFacing.Turn(degrees); err != nil {
+		t.emit(EventRefused, "TurnRobot", err)
+		return t.robotFacing, err
+	}
+	t.emit(EventRotated, "TurnRobot", nil)
 	return t.robotFacing, nil
 }
 
 func (t *Table) Report() error {
+	return t.observe("Report", nil, t.report)
+}
+
+func (t *Table) report() error {
 	if t.robotPosition == nil {
+		t.emit(EventRefused, "Report", ErrUninitializedPlacement)
 		return ErrUninitializedPlacement
 	}
 
 	_, err := fmt.Fprintf(t.reportOutput, "Robot position: (%d, %d) facing: %s\n", t.robotPosition.X, t.robotPosition.Y, t.robotFacing)
+	t.emit(EventReported, "Report", err)
 	return err
 }
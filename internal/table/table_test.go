@@ -2,6 +2,7 @@ package table_test
 
 import (
 	"bytes"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -42,6 +43,22 @@ func TestPlaceRobot(t *testing.T) {
 			facing:   direction.West,
 			expected: table.ErrEndingPositionOutOfBounds,
 		},
+		{
+			name:     "should fail to place a robot on blocked cell",
+			tbl:      table.New(5, 5, table.WithBlocked(point.Point{X: 2, Y: 3})),
+			pos:      point.Point{X: 2, Y: 3},
+			facing:   direction.West,
+			expected: table.ErrPositionBlocked,
+		},
+		{
+			name: "should fail to place a robot on cell blocked by func",
+			tbl: table.New(5, 5, table.WithBlockedFunc(func(pos point.Point) bool {
+				return pos == point.Point{X: 4, Y: 0}
+			})),
+			pos:      point.Point{X: 4, Y: 0},
+			facing:   direction.West,
+			expected: table.ErrPositionBlocked,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,6 +124,201 @@ func TestMoveRobot(t *testing.T) {
 	}
 }
 
+func TestMoveRobotBy(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name        string
+		tbl         func() *table.Table
+		steps       int
+		partial     bool
+		expectedPos *point.Point
+		expectedErr error
+	}{
+		{
+			name: "should ignore move command when placement was not done",
+			tbl: func() *table.Table {
+				return table.New(5, 5)
+			},
+			steps:       2,
+			expectedPos: nil,
+			expectedErr: table.ErrUninitializedPlacement,
+		},
+		{
+			name: "should successfully move a robot by several steps",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				return tbl
+			},
+			steps:       3,
+			expectedPos: &point.Point{X: 1, Y: 4},
+			expectedErr: nil,
+		},
+		{
+			name: "should successfully move a robot backwards",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 3, Y: 1}, direction.East)
+				return tbl
+			},
+			steps:       -2,
+			expectedPos: &point.Point{X: 1, Y: 1},
+			expectedErr: nil,
+		},
+		{
+			name: "should stop partial move in front of blocked cell",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5, table.WithBlocked(point.Point{X: 1, Y: 4}))
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				return tbl
+			},
+			steps:       3,
+			partial:     true,
+			expectedPos: &point.Point{X: 1, Y: 3},
+			expectedErr: nil,
+		},
+		{
+			name: "should stop partial move in front of cell blocked by func",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5, table.WithBlockedFunc(func(pos point.Point) bool {
+					return pos.X == 3
+				}))
+				tbl.PlaceRobot(point.Point{X: 0, Y: 0}, direction.East)
+				return tbl
+			},
+			steps:       4,
+			partial:     true,
+			expectedPos: &point.Point{X: 2, Y: 0},
+			expectedErr: nil,
+		},
+		{
+			name: "should ignore whole move that would push robot out of the board",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 2}, direction.West)
+				return tbl
+			},
+			steps:       3,
+			expectedPos: &point.Point{X: 1, Y: 2},
+			expectedErr: table.ErrEndingPositionOutOfBounds,
+		},
+		{
+			name: "should move partially until the edge of the board",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 2}, direction.West)
+				return tbl
+			},
+			steps:       3,
+			partial:     true,
+			expectedPos: &point.Point{X: 0, Y: 2},
+			expectedErr: nil,
+		},
+		{
+			name: "should refuse partial move when no step can be taken",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 0, Y: 2}, direction.West)
+				return tbl
+			},
+			steps:       3,
+			partial:     true,
+			expectedPos: &point.Point{X: 0, Y: 2},
+			expectedErr: table.ErrEndingPositionOutOfBounds,
+		},
+		{
+			name: "should refuse a huge move without allocating for its steps",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 2}, direction.North)
+				return tbl
+			},
+			steps:       math.MaxInt,
+			expectedPos: &point.Point{X: 1, Y: 2},
+			expectedErr: table.ErrEndingPositionOutOfBounds,
+		},
+		{
+			name: "should move partially by the smallest int until the edge",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 2}, direction.North)
+				return tbl
+			},
+			steps:       math.MinInt,
+			partial:     true,
+			expectedPos: &point.Point{X: 1, Y: 0},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := tt.tbl().MoveRobotBy(tt.steps, tt.partial)
+			require.Equal(t, tt.expectedPos, actual)
+			require.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestTurnRobot(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		tbl            func() *table.Table
+		degrees        int
+		expectedFacing *direction.Direction
+		expectedErr    error
+	}{
+		{
+			name: "should ignore turn command when placement was not done",
+			tbl: func() *table.Table {
+				return table.New(5, 5)
+			},
+			degrees:        180,
+			expectedFacing: nil,
+			expectedErr:    table.ErrUninitializedPlacement,
+		},
+		{
+			name: "should successfully turn robot around",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				return tbl
+			},
+			degrees:        180,
+			expectedFacing: &direction.South,
+			expectedErr:    nil,
+		},
+		{
+			name: "should fail to turn robot by invalid angle",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 1, Y: 1}, direction.North)
+				return tbl
+			},
+			degrees:        30,
+			expectedFacing: &direction.North,
+			expectedErr:    direction.ErrInvalidAngle,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := tt.tbl().TurnRobot(tt.degrees)
+			require.Equal(t, tt.expectedFacing, actual)
+			require.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
 func TestRotateRobot(t *testing.T) {
 	t.Parallel()
 
@@ -204,3 +416,80 @@ func TestReport(t *testing.T) {
 		})
 	}
 }
+
+func TestRobot(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name           string
+		tbl            func() *table.Table
+		expectedPos    point.Point
+		expectedFacing direction.Direction
+		expectedErr    error
+	}{
+		{
+			name: "should fail to return robot when placement was not done",
+			tbl: func() *table.Table {
+				return table.New(5, 5)
+			},
+			expectedErr: table.ErrUninitializedPlacement,
+		},
+		{
+			name: "should return robots position and facing",
+			tbl: func() *table.Table {
+				tbl := table.New(5, 5)
+				tbl.PlaceRobot(point.Point{X: 2, Y: 3}, direction.West)
+				return tbl
+			},
+			expectedPos:    point.Point{X: 2, Y: 3},
+			expectedFacing: direction.West,
+			expectedErr:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pos, facing, err := tt.tbl().Robot()
+			require.Equal(t, tt.expectedPos, pos)
+			require.Equal(t, tt.expectedFacing, facing)
+			require.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name      string
+		src       string
+		expectedX uint
+		expectedY uint
+		shouldErr bool
+	}{
+		{name: "should parse table size", src: "5x5", expectedX: 5, expectedY: 5},
+		{name: "should parse upper case separator", src: " 9X3 ", expectedX: 9, expectedY: 3},
+		{name: "should fail to parse size without height", src: "5", shouldErr: true},
+		{name: "should fail to parse zero size", src: "0x4", shouldErr: true},
+		{name: "should fail to parse negative size", src: "4x-1", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sizeX, sizeY, err := table.ParseSize(tt.src)
+			if tt.shouldErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedX, sizeX)
+			require.Equal(t, tt.expectedY, sizeY)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package websocket
+
+import "errors"
+
+var (
+	ErrBadHandshake       error = errors.New("bad websocket handshake")
+	ErrOriginNotAllowed   error = errors.New("websocket origin not allowed")
+	ErrHijackNotSupported error = errors.New("connection can not be taken over")
+	ErrProtocol           error = errors.New("websocket protocol error")
+	ErrMessageTooBig      error = errors.New("websocket message too big")
+	ErrInvalidUTF8        error = errors.New("websocket text message is not valid UTF-8")
+	ErrClosed             error = errors.New("websocket closed")
+)
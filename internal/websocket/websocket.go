@@ -0,0 +1,465 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// acceptGUID is appended to the handshake key to compute the accept key
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MaxMessageSize limits the size of received messages
+const MaxMessageSize = 1 << 20
+
+// MessageType is the type of a data message
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// opcodes of frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	CloseMessageTooBig   = 1009
+	CloseInternalFailure = 1011
+)
+
+// CloseError is returned by `ReadMessage` once the peer closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed: %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection, reads must not be concurrent while writes
+// may be made from several goroutines
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	// client connections mask the frames they send while servers require
+	// received frames to be masked
+	client bool
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Option is an option that can be passed to `Upgrade`
+type Option func(*upgrader)
+
+type upgrader struct {
+	origins map[string]bool
+}
+
+// WithOrigins provides an option to accept handshakes sent by pages of the
+// origins, e.g. https://example.com, in addition to pages served by the host
+// of the request. The "*" origin accepts every page
+func WithOrigins(origins ...string) Option {
+	return func(u *upgrader) {
+		for _, origin := range origins {
+			u.origins[strings.ToLower(origin)] = true
+		}
+	}
+}
+
+// Upgrade performs the opening handshake of the request and takes over its
+// connection, an error response is written when the request is not a valid
+// WebSocket handshake. Browsers name the page opening the connection in the
+// Origin header, handshakes of pages served by another host than the one of
+// the request are refused unless the origin is allowed by an option
+func Upgrade(w http.ResponseWriter, r *http.Request, opts ...Option) (*Conn, error) {
+	u := &upgrader{origins: map[string]bool{}}
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	if err := checkHandshake(r); err != nil {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, err
+	}
+	if err := u.checkOrigin(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, err
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, ErrHijackNotSupported.Error(), http.StatusInternalServerError)
+		return nil, ErrHijackNotSupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(r.Header.Get("Sec-WebSocket-Key")))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, r: rw.Reader}, nil
+}
+
+// checkHandshake validates the opening handshake sent by the client
+func checkHandshake(r *http.Request) error {
+	switch {
+	case r.Method != http.MethodGet:
+		return fmt.Errorf("%w: method %s", ErrBadHandshake, r.Method)
+	case !headerContains(r.Header, "Connection", "upgrade"):
+		return fmt.Errorf("%w: missing Connection: Upgrade", ErrBadHandshake)
+	case !headerContains(r.Header, "Upgrade", "websocket"):
+		return fmt.Errorf("%w: missing Upgrade: websocket", ErrBadHandshake)
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return fmt.Errorf("%w: unsupported version '%s'", ErrBadHandshake, r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key"))
+	if err != nil || len(key) != 16 {
+		return fmt.Errorf("%w: invalid Sec-WebSocket-Key", ErrBadHandshake)
+	}
+	return nil
+}
+
+// checkOrigin validates the Origin header of the handshake, requests without
+// it are not sent by browsers and are accepted
+func (u *upgrader) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || u.origins["*"] || u.origins[strings.ToLower(origin)] {
+		return nil
+	}
+	parsed, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOriginNotAllowed, origin)
+}
+
+// headerContains reports whether the comma separated header holds the token
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Dial opens a client connection to the ws:// URL
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("%w: unsupported scheme '%s'", ErrBadHandshake, u.Scheme)
+	}
+
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+u.Host+u.RequestURI(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: server responded with %s", ErrBadHandshake, resp.Status)
+	}
+	return &Conn{conn: conn, r: r, client: true}, nil
+}
+
+// ReadMessage returns the next data message, pings are answered while
+// waiting for it. A `CloseError` is returned once the peer closes the
+// connection and the close is confirmed before returning it
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		typ     MessageType
+		message []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr, err := parseClose(payload)
+			if err != nil {
+				return 0, nil, c.fail(err)
+			}
+			code := closeErr.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			c.Close(code, "")
+			return 0, nil, closeErr
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, c.fail(fmt.Errorf("%w: new message before the end of the fragmented one", ErrProtocol))
+			}
+			typ = MessageType(op)
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, c.fail(fmt.Errorf("%w: continuation without a message", ErrProtocol))
+			}
+		default:
+			return 0, nil, c.fail(fmt.Errorf("%w: unknown opcode %d", ErrProtocol, op))
+		}
+
+		if len(message)+len(payload) > MaxMessageSize {
+			return 0, nil, c.fail(ErrMessageTooBig)
+		}
+		message = append(message, payload...)
+		if !fin {
+			continue
+		}
+		if typ == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(ErrInvalidUTF8)
+		}
+		return typ, message, nil
+	}
+}
+
+// parseClose returns the code and the reason of the close frame payload, the
+// payload is either empty or holds a code that may be sent by a peer
+// followed by a UTF-8 reason
+func parseClose(payload []byte) (*CloseError, error) {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatus}, nil
+	case len(payload) == 1:
+		return nil, fmt.Errorf("%w: close frame without a complete code", ErrProtocol)
+	}
+
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return nil, fmt.Errorf("%w: invalid close code %d", ErrProtocol, code)
+	}
+	if !utf8.Valid(payload[2:]) {
+		return nil, ErrInvalidUTF8
+	}
+	return &CloseError{Code: code, Reason: string(payload[2:])}, nil
+}
+
+// validCloseCode reports whether the code may be sent in a close frame, codes
+// 1004, 1005, 1006 and 1015 are reserved and others below 3000 are not defined
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// readFrame reads a single frame and unmasks its payload
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", ErrProtocol)
+	}
+	if masked == c.client {
+		return false, 0, nil, fmt.Errorf("%w: invalid masking", ErrProtocol)
+	}
+
+	size := uint64(header[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose && (!fin || size > 125) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", ErrProtocol)
+	}
+	if size > MaxMessageSize {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// fail closes the connection with the close code matching the error
+func (c *Conn) fail(err error) error {
+	switch {
+	case errors.Is(err, ErrProtocol):
+		c.Close(CloseProtocolError, "")
+	case errors.Is(err, ErrMessageTooBig):
+		c.Close(CloseMessageTooBig, "")
+	case errors.Is(err, ErrInvalidUTF8):
+		c.Close(CloseInvalidPayload, "")
+	default:
+		c.conn.Close()
+	}
+	return err
+}
+
+// WriteMessage sends the data message in a single frame
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	return c.writeFrame(byte(typ), data)
+}
+
+// writeFrame sends a single final frame
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *Conn) writeFrameLocked(op byte, payload []byte) error {
+	frame := []byte{0x80 | op}
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	_, err := c.conn.Write(append(frame, payload...))
+	return err
+}
+
+// Close sends the close frame with the code and the reason and closes the
+// connection, closing an already closed connection does nothing
+func (c *Conn) Close(code int, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	c.writeFrameLocked(opClose, payload)
+	return c.conn.Close()
+}
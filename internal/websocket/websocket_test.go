@@ -0,0 +1,239 @@
+package websocket_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"robot/internal/websocket"
+)
+
+// echo sends every received message back until the connection is closed
+func echo(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	for {
+		typ, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(typ, msg); err != nil {
+			return
+		}
+	}
+}
+
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(echo))
+	defer ts.Close()
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(ts.URL, "http"))
+	require.NoError(t, err)
+
+	for _, msg := range []string{"MOVE", strings.Repeat("LEFT\n", 100), strings.Repeat("x", 70000)} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		typ, actual, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, websocket.TextMessage, typ)
+		require.Equal(t, msg, string(actual))
+	}
+
+	require.NoError(t, conn.Close(websocket.CloseNormal, "bye"))
+	require.ErrorIs(t, conn.WriteMessage(websocket.TextMessage, []byte("MOVE")), websocket.ErrClosed)
+}
+
+func TestUpgradeRejectsPlainRequest(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(echo))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpgradeChecksOrigin(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		origins  []string
+		origin   string
+		expected int
+	}{
+		{
+			name:     "should accept request without origin",
+			expected: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "should accept page served by the host",
+			origin:   "http://robot",
+			expected: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "should refuse page served by another host",
+			origin:   "https://example.com",
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "should accept allowed origin",
+			origins:  []string{"https://Example.com"},
+			origin:   "https://example.com",
+			expected: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "should accept any origin",
+			origins:  []string{"*"},
+			origin:   "https://example.com",
+			expected: http.StatusSwitchingProtocols,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := websocket.Upgrade(w, r, websocket.WithOrigins(tt.origins...))
+				if err == nil {
+					conn.Close(websocket.CloseNormal, "")
+				}
+			}))
+			defer ts.Close()
+
+			conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+			require.NoError(t, err)
+			defer conn.Close()
+
+			req := "GET / HTTP/1.1\r\nHost: robot\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"
+			if tt.origin != "" {
+				req += "Origin: " + tt.origin + "\r\n"
+			}
+			_, err = io.WriteString(conn, req+"\r\n")
+			require.NoError(t, err)
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
+
+// frame returns a client frame masked with a zero key so that the payload is
+// sent as is
+func frame(fin bool, op byte, payload string) []byte {
+	b := op
+	if fin {
+		b |= 0x80
+	}
+	return append([]byte{b, 0x80 | byte(len(payload)), 0, 0, 0, 0}, payload...)
+}
+
+// serverFrame returns an unmasked frame as sent by the server
+func serverFrame(op byte, payload string) []byte {
+	return append([]byte{0x80 | op, byte(len(payload))}, payload...)
+}
+
+func TestFrames(t *testing.T) {
+	t.Parallel()
+
+	tests := [...]struct {
+		name     string
+		frames   [][]byte
+		expected []byte
+	}{
+		{
+			name: "should join fragments and answer pings in between",
+			frames: [][]byte{
+				frame(false, 0x1, "MO"),
+				frame(true, 0x9, "hi"),
+				frame(true, 0x0, "VE"),
+			},
+			expected: append(serverFrame(0xa, "hi"), serverFrame(0x1, "MOVE")...),
+		},
+		{
+			name:     "should confirm close",
+			frames:   [][]byte{frame(true, 0x8, "\x03\xe8")},
+			expected: serverFrame(0x8, "\x03\xe8"),
+		},
+		{
+			name:     "should confirm close with an application code",
+			frames:   [][]byte{frame(true, 0x8, "\x0b\xb8bye")},
+			expected: serverFrame(0x8, "\x0b\xb8"),
+		},
+		{
+			name:     "should close on close frame with a partial code",
+			frames:   [][]byte{frame(true, 0x8, "\x03")},
+			expected: serverFrame(0x8, "\x03\xea"),
+		},
+		{
+			name:     "should close on close frame with a reserved code",
+			frames:   [][]byte{frame(true, 0x8, "\x03\xed")},
+			expected: serverFrame(0x8, "\x03\xea"),
+		},
+		{
+			name:     "should close on close frame with an undefined code",
+			frames:   [][]byte{frame(true, 0x8, "\x03\xe7")},
+			expected: serverFrame(0x8, "\x03\xea"),
+		},
+		{
+			name:     "should close on unmasked frame",
+			frames:   [][]byte{serverFrame(0x1, "MOVE")},
+			expected: serverFrame(0x8, "\x03\xea"),
+		},
+		{
+			name:     "should close on invalid UTF-8",
+			frames:   [][]byte{frame(true, 0x1, "\xff")},
+			expected: serverFrame(0x8, "\x03\xef"),
+		},
+		{
+			name:     "should close on continuation without a message",
+			frames:   [][]byte{frame(true, 0x0, "VE")},
+			expected: serverFrame(0x8, "\x03\xea"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(echo))
+			defer ts.Close()
+
+			conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: robot\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+			require.NoError(t, err)
+			r := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(r, nil)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+			for _, f := range tt.frames {
+				_, err := conn.Write(f)
+				require.NoError(t, err)
+			}
+			actual := make([]byte, len(tt.expected))
+			_, err = io.ReadFull(r, actual)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}